@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/handlers"
+	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/store"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bootstrapAdmin makes ADMIN_USERNAME an admin when the system has no admin
+// yet, creating the account with ADMIN_PASSWORD if it does not exist. An
+// existing account is only promoted when ADMIN_PASSWORD matches, so nobody
+// can claim the admin username by registering it first. It reports whether
+// an admin was created or promoted.
+func bootstrapAdmin(ctx context.Context, users store.UserStore) (bool, error) {
+	username := os.Getenv("ADMIN_USERNAME")
+	password := os.Getenv("ADMIN_PASSWORD")
+	if username == "" || password == "" {
+		return false, errors.New("ADMIN_USERNAME and ADMIN_PASSWORD must both be set")
+	}
+
+	all, err := users.ListUsers(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, u := range all {
+		if u.Role == "admin" {
+			return false, nil
+		}
+	}
+
+	user, err := users.GetUserByUsername(ctx, username)
+	if errors.Is(err, store.ErrNotFound) {
+		user, err = handlers.NewUser(username, password, "user")
+		if err != nil {
+			return false, err
+		}
+		if err := users.CreateUser(ctx, &user); err != nil {
+			return false, err
+		}
+	} else if err != nil {
+		return false, err
+	} else if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return false, fmt.Errorf("user %q already exists with a different password", username)
+	}
+
+	change := models.RoleChange{UserID: user.ID, NewRole: "admin", ChangedAt: time.Now().UTC()}
+	if err := users.SetUserRole(ctx, &change); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// runBootstrapAdmin implements the "bootstrap-admin" command.
+func runBootstrapAdmin(s store.Store) {
+	created, err := bootstrapAdmin(context.Background(), s)
+	if err != nil {
+		log.Fatalf("bootstrap-admin: %v", err)
+	}
+	if created {
+		fmt.Printf("%s is now an admin\n", os.Getenv("ADMIN_USERNAME"))
+	} else {
+		fmt.Println("An admin already exists; nothing to do")
+	}
+}
@@ -0,0 +1,113 @@
+// Package blobs stores the contents of uploaded files outside the database.
+package blobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ErrNotFound is returned when no blob is stored under a key.
+var ErrNotFound = errors.New("blobs: not found")
+
+// BlobStore stores opaque blobs under keys it chooses itself.
+type BlobStore interface {
+	// Put stores everything read from r under a new key and returns the key
+	// and the number of bytes stored. Nothing is kept if reading r fails.
+	Put(ctx context.Context, r io.Reader) (key string, size int64, err error)
+	// Open returns the blob stored under key, or ErrNotFound.
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Delete removes the blob stored under key; deleting a missing blob is
+	// not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// FileStore is a BlobStore that keeps each blob as a file in Dir.
+type FileStore struct {
+	Dir string
+}
+
+var _ BlobStore = (*FileStore)(nil)
+
+// NewFileStore returns a FileStore keeping its blobs in dir, creating the
+// directory if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &FileStore{Dir: dir}, nil
+}
+
+func (s *FileStore) Put(ctx context.Context, r io.Reader) (string, int64, error) {
+	key, err := newKey()
+	if err != nil {
+		return "", 0, err
+	}
+	// Write to a temporary file first so a failed upload never leaves a
+	// partial blob under its key.
+	tmp, err := os.CreateTemp(s.Dir, ".upload-*")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmp.Name())
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return "", 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.Dir, key)); err != nil {
+		return "", 0, err
+	}
+	return key, size, nil
+}
+
+func (s *FileStore) Open(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	path, ok := s.path(key)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (s *FileStore) Delete(ctx context.Context, key string) error {
+	path, ok := s.path(key)
+	if !ok {
+		return nil
+	}
+	err := os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// path returns the file holding the blob stored under key, refusing keys
+// that Put could not have returned.
+func (s *FileStore) path(key string) (string, bool) {
+	if len(key) != 32 {
+		return "", false
+	}
+	if _, err := hex.DecodeString(key); err != nil {
+		return "", false
+	}
+	return filepath.Join(s.Dir, key), true
+}
+
+func newKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
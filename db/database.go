@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
@@ -8,11 +9,18 @@ import (
 
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 )
 
-var DB *sql.DB
+// Supported values of DB_DRIVER.
+const (
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+	Memory   = "memory"
+)
 
-func InitDB() {
+// Driver returns the configured DB_DRIVER, defaulting to Postgres.
+func Driver() string {
 	// Load .env only if not in Railway
 	if os.Getenv("RAILWAY_ENVIRONMENT") == "" {
 		if err := godotenv.Load(); err != nil {
@@ -20,6 +28,27 @@ func InitDB() {
 		}
 	}
 
+	if driver := os.Getenv("DB_DRIVER"); driver != "" {
+		return driver
+	}
+	return Postgres
+}
+
+// Open connects to the database selected by DB_DRIVER: Postgres using the
+// DB_* connection variables, or the SQLite file at DB_PATH.
+func Open(driver string) *sql.DB {
+	switch driver {
+	case Postgres:
+		return openPostgres()
+	case SQLite:
+		return openSQLite()
+	default:
+		log.Fatalf("Unsupported DB_DRIVER %q", driver)
+		return nil
+	}
+}
+
+func openPostgres() *sql.DB {
 	// Get environment variables
 	dbHost := os.Getenv("DB_HOST")
 	dbPort := os.Getenv("DB_PORT")
@@ -40,34 +69,39 @@ func InitDB() {
 	)
 
 	// Open database connection
-	var err error
-	DB, err = sql.Open("postgres", connStr)
+	conn, err := sql.Open("postgres", connStr)
 	if err != nil {
 		log.Fatalf("Failed to open DB: %v", err)
 	}
+	return conn
+}
 
-	// Create users table
-	createUsersTable := `
-	CREATE TABLE IF NOT EXISTS users(
-		id SERIAL PRIMARY KEY,
-		username TEXT UNIQUE NOT NULL,
-		password TEXT NOT NULL,
-		role TEXT NOT NULL CHECK(role IN ('admin', 'user'))
-	);`
-	if _, err = DB.Exec(createUsersTable); err != nil {
-		log.Fatalf("Failed to create users table: %v", err)
+func openSQLite() *sql.DB {
+	path := os.Getenv("DB_PATH")
+	if path == "" {
+		path = "todos.db"
 	}
 
-	// Create todos table
-	createTodosTable := `
-	CREATE TABLE IF NOT EXISTS todos(
-		id SERIAL PRIMARY KEY,
-		title TEXT NOT NULL,
-		completed BOOLEAN NOT NULL DEFAULT false,
-		user_id INTEGER NOT NULL,
-		FOREIGN KEY(user_id) REFERENCES users(id)
-	);`
-	if _, err = DB.Exec(createTodosTable); err != nil {
-		log.Fatalf("Failed to create todos table: %v", err)
+	// Foreign keys are off by default in SQLite. Immediate transactions and a
+	// busy timeout make concurrent writers wait for each other instead of
+	// failing with SQLITE_BUSY.
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+	conn, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		log.Fatalf("Failed to open DB: %v", err)
+	}
+	return conn
+}
+
+// InitDB opens the database and applies any pending migrations.
+func InitDB(driver string) *sql.DB {
+	conn := Open(driver)
+	n, err := MigrateUp(context.Background(), conn, driver)
+	if err != nil {
+		log.Fatalf("Failed to migrate DB: %v", err)
+	}
+	if n > 0 {
+		log.Printf("Applied %d migration(s)", n)
 	}
+	return conn
 }
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS tags(
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	UNIQUE(user_id, name)
+);
+CREATE TABLE IF NOT EXISTS todo_tags(
+	todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+	tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+	PRIMARY KEY(todo_id, tag_id)
+);
+CREATE INDEX IF NOT EXISTS todo_tags_tag_id_idx ON todo_tags(tag_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS todos_user_created_at_idx;
+ALTER TABLE todos DROP COLUMN created_at;
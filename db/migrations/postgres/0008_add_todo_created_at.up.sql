@@ -0,0 +1,3 @@
+-- Todos created before this migration get the time it ran.
+ALTER TABLE todos ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+CREATE INDEX IF NOT EXISTS todos_user_created_at_idx ON todos(user_id, created_at, id);
@@ -0,0 +1,17 @@
+-- A series holds what the occurrences of a recurring todo have in common.
+-- Each occurrence is a todo pointing at its series; the next one is created
+-- when the current one is completed.
+CREATE TABLE IF NOT EXISTS todo_series(
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	title TEXT NOT NULL,
+	project_id INTEGER REFERENCES projects(id),
+	rrule TEXT NOT NULL,
+	timezone TEXT NOT NULL DEFAULT 'UTC',
+	dtstart TIMESTAMPTZ NOT NULL
+);
+
+ALTER TABLE todos ADD COLUMN series_id INTEGER REFERENCES todo_series(id) ON DELETE SET NULL;
+-- At most one occurrence of a series per due time, so completing the same
+-- occurrence twice cannot create its successor twice.
+CREATE UNIQUE INDEX IF NOT EXISTS todos_series_due_at_idx ON todos(series_id, due_at) WHERE series_id IS NOT NULL;
@@ -0,0 +1,14 @@
+-- Attachments are deleted together with their todo. The files themselves are
+-- kept outside the database and removed by the API.
+CREATE TABLE IF NOT EXISTS attachments(
+	id SERIAL PRIMARY KEY,
+	todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	filename TEXT NOT NULL,
+	content_type TEXT NOT NULL,
+	size BIGINT NOT NULL,
+	blob_key TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS attachments_todo_id_idx ON attachments(todo_id);
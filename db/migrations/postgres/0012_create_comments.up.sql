@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS comments(
+	id SERIAL PRIMARY KEY,
+	todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	body TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS comments_todo_id_idx ON comments(todo_id);
@@ -0,0 +1,2 @@
+ALTER TABLE todos ADD COLUMN assignee_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS todos_assignee_id_idx ON todos(assignee_id);
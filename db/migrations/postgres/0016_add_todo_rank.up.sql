@@ -0,0 +1,5 @@
+-- rank orders a user's todos by plain byte comparison, hence the C
+-- collation. Existing todos start unranked and get keys from the rank
+-- rebalancer.
+ALTER TABLE todos ADD COLUMN rank TEXT COLLATE "C" NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS todos_user_id_rank_idx ON todos(user_id, rank);
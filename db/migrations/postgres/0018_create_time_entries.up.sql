@@ -0,0 +1,15 @@
+-- Time tracked by a user on a todo. A null stopped_at marks the user's
+-- running timer, of which there is at most one.
+CREATE TABLE IF NOT EXISTS time_entries(
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+	started_at TIMESTAMPTZ NOT NULL,
+	stopped_at TIMESTAMPTZ,
+	note TEXT NOT NULL DEFAULT '',
+	CHECK(stopped_at IS NULL OR stopped_at >= started_at)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS time_entries_running_idx ON time_entries(user_id) WHERE stopped_at IS NULL;
+CREATE INDEX IF NOT EXISTS time_entries_user_id_started_at_idx ON time_entries(user_id, started_at);
+CREATE INDEX IF NOT EXISTS time_entries_todo_id_idx ON time_entries(todo_id);
@@ -0,0 +1,2 @@
+ALTER TABLE todos ADD COLUMN priority TEXT NOT NULL DEFAULT 'none'
+	CHECK (priority IN ('none', 'low', 'medium', 'high', 'urgent'));
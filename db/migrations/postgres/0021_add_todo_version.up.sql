@@ -0,0 +1,3 @@
+-- version counts the writes to a todo; clients send it back in If-Match to
+-- avoid overwriting each other's changes.
+ALTER TABLE todos ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
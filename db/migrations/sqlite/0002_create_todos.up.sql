@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS todos(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	title TEXT NOT NULL,
+	completed BOOLEAN NOT NULL DEFAULT false,
+	user_id INTEGER NOT NULL,
+	FOREIGN KEY(user_id) REFERENCES users(id)
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS role_changes(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	old_role TEXT NOT NULL,
+	new_role TEXT NOT NULL,
+	changed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+	changed_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS role_changes_user_id_idx ON role_changes(user_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS todos_pending_reminders_idx;
+DROP INDEX IF EXISTS todos_due_at_idx;
+ALTER TABLE todos DROP COLUMN reminder_lease_until;
+ALTER TABLE todos DROP COLUMN reminded_at;
+ALTER TABLE todos DROP COLUMN remind_at;
+ALTER TABLE todos DROP COLUMN due_at;
@@ -0,0 +1,6 @@
+ALTER TABLE todos ADD COLUMN due_at TIMESTAMP;
+ALTER TABLE todos ADD COLUMN remind_at TIMESTAMP;
+ALTER TABLE todos ADD COLUMN reminded_at TIMESTAMP;
+ALTER TABLE todos ADD COLUMN reminder_lease_until TIMESTAMP;
+CREATE INDEX IF NOT EXISTS todos_due_at_idx ON todos(due_at);
+CREATE INDEX IF NOT EXISTS todos_pending_reminders_idx ON todos(remind_at) WHERE reminded_at IS NULL;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS projects(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	color TEXT NOT NULL DEFAULT '',
+	archived BOOLEAN NOT NULL DEFAULT false,
+	position INTEGER NOT NULL DEFAULT 0,
+	is_inbox BOOLEAN NOT NULL DEFAULT false
+);
+CREATE INDEX IF NOT EXISTS projects_user_id_idx ON projects(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS projects_one_inbox_idx ON projects(user_id) WHERE is_inbox;
+
+ALTER TABLE todos ADD COLUMN project_id INTEGER REFERENCES projects(id);
+CREATE INDEX IF NOT EXISTS todos_project_id_idx ON todos(project_id);
+
+-- Every existing user gets an Inbox holding all of their todos.
+INSERT INTO projects(user_id, name, is_inbox) SELECT id, 'Inbox', true FROM users;
+UPDATE todos SET project_id = (SELECT p.id FROM projects p WHERE p.user_id = todos.user_id AND p.is_inbox);
@@ -0,0 +1,7 @@
+-- SQLite cannot add a column defaulting to the current time, so existing
+-- todos are backfilled with the time this migration ran. It is written the
+-- way the driver writes time values (time.Time.String in UTC) so that both
+-- compare correctly as text.
+ALTER TABLE todos ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT '1970-01-01 00:00:00 +0000 UTC';
+UPDATE todos SET created_at = datetime('now') || ' +0000 UTC';
+CREATE INDEX IF NOT EXISTS todos_user_created_at_idx ON todos(user_id, created_at, id);
@@ -0,0 +1,3 @@
+-- Deleting a todo deletes its subtasks with it.
+ALTER TABLE todos ADD COLUMN parent_id INTEGER REFERENCES todos(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS todos_parent_id_idx ON todos(parent_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS todos_series_due_at_idx;
+ALTER TABLE todos DROP COLUMN series_id;
+DROP TABLE IF EXISTS todo_series;
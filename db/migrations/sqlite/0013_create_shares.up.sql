@@ -0,0 +1,14 @@
+-- A share grants grantee_id access to one of owner_id's todos, and its
+-- subtasks, or to all of owner_id's todos when todo_id is null.
+CREATE TABLE IF NOT EXISTS shares(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	grantee_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	todo_id INTEGER REFERENCES todos(id) ON DELETE CASCADE,
+	role TEXT NOT NULL CHECK(role IN ('viewer', 'editor')),
+	created_at TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS shares_owner_grantee_idx ON shares(owner_id, grantee_id) WHERE todo_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS shares_todo_grantee_idx ON shares(todo_id, grantee_id) WHERE todo_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS shares_grantee_id_idx ON shares(grantee_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS todos_assignee_id_idx;
+ALTER TABLE todos DROP COLUMN assignee_id;
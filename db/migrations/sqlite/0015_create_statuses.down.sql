@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS todos_status_id_idx;
+ALTER TABLE todos DROP COLUMN status_id;
+DROP TABLE IF EXISTS status_transitions;
+DROP TABLE IF EXISTS statuses;
@@ -0,0 +1,30 @@
+-- Every user has their own ordered set of workflow statuses. A todo's
+-- completed flag follows the is_done flag of its status.
+CREATE TABLE IF NOT EXISTS statuses(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	position INTEGER NOT NULL DEFAULT 0,
+	is_done BOOLEAN NOT NULL DEFAULT false,
+	UNIQUE(user_id, name)
+);
+
+-- The statuses a todo may move to from from_id. A status without rows here
+-- may move to any other.
+CREATE TABLE IF NOT EXISTS status_transitions(
+	from_id INTEGER NOT NULL REFERENCES statuses(id) ON DELETE CASCADE,
+	to_id INTEGER NOT NULL REFERENCES statuses(id) ON DELETE CASCADE,
+	PRIMARY KEY(from_id, to_id)
+);
+
+ALTER TABLE todos ADD COLUMN status_id INTEGER REFERENCES statuses(id);
+CREATE INDEX IF NOT EXISTS todos_status_id_idx ON todos(status_id);
+
+-- Existing users get the default statuses, and their todos land in Backlog
+-- or Done.
+INSERT INTO statuses(user_id, name, position, is_done) SELECT id, 'Backlog', 0, false FROM users;
+INSERT INTO statuses(user_id, name, position, is_done) SELECT id, 'In Progress', 1, false FROM users;
+INSERT INTO statuses(user_id, name, position, is_done) SELECT id, 'Review', 2, false FROM users;
+INSERT INTO statuses(user_id, name, position, is_done) SELECT id, 'Done', 3, true FROM users;
+UPDATE todos SET status_id = (SELECT s.id FROM statuses s WHERE s.user_id = todos.user_id
+	AND s.name = CASE WHEN todos.completed THEN 'Done' ELSE 'Backlog' END);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS todos_user_id_rank_idx;
+ALTER TABLE todos DROP COLUMN rank;
@@ -0,0 +1,9 @@
+-- todo_id is blocked by blocker_id until the blocker is completed.
+CREATE TABLE IF NOT EXISTS todo_dependencies(
+	todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+	blocker_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+	PRIMARY KEY(todo_id, blocker_id),
+	CHECK(todo_id <> blocker_id)
+);
+
+CREATE INDEX IF NOT EXISTS todo_dependencies_blocker_id_idx ON todo_dependencies(blocker_id);
@@ -0,0 +1,4 @@
+-- Deleted todos stay in the trash, with deleted_at set, until they are
+-- restored or purged.
+ALTER TABLE todos ADD COLUMN deleted_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS todos_deleted_at_idx ON todos(deleted_at) WHERE deleted_at IS NOT NULL;
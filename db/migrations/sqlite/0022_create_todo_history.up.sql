@@ -0,0 +1,13 @@
+-- One entry per change to a todo. changes maps each changed field to its
+-- value before and after, as a JSON object of {"from": ..., "to": ...}.
+CREATE TABLE IF NOT EXISTS todo_history(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+	actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+	action TEXT NOT NULL,
+	version INTEGER NOT NULL,
+	changes TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS todo_history_todo_id_idx ON todo_history(todo_id);
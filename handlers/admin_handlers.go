@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/store"
+)
+
+// AdminHandler serves the user management endpoints under /admin.
+type AdminHandler struct {
+	Users store.UserStore
+}
+
+func NewAdminHandler(users store.UserStore) *AdminHandler {
+	return &AdminHandler{Users: users}
+}
+
+// Get all users
+// @Summary Get all users (Admin Only)
+// @Description Admin can get all users
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Success 200 {string} string "Get Users"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden (Admins only)"
+// @Failure 500 {string} string "Server error"
+// @Router /admin/getallusers [get]
+func (h *AdminHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
+	role := r.Context().Value("role").(string)
+	if role != "admin" {
+		http.Error(w, "Only admin can see all users", http.StatusForbidden)
+		return
+	}
+	users, err := h.Users.ListUsers(r.Context())
+	if err != nil {
+		http.Error(w, "Error with fetching all users", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(users)
+}
+
+// SetUserRole promotes or demotes a user
+// @Summary Change user role (Admin Only)
+// @Description Promote a user to admin or demote an admin to user. The change is recorded; the last admin cannot be demoted.
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param role body models.RoleModel true "New role (admin or user)"
+// @Success 200 {object} models.RoleChange
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden (Admins only)"
+// @Failure 404 {string} string "User not found"
+// @Failure 409 {string} string "Cannot demote the last admin"
+// @Failure 500 {string} string "Server error"
+// @Router /admin/users/{id}/role [put]
+func (h *AdminHandler) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value("user_id").(int)
+
+	userID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+	var req models.RoleModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.Role != "admin" && req.Role != "user" {
+		http.Error(w, "Role must be admin or user", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.Users.GetUser(r.Context(), userID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	change := models.RoleChange{
+		UserID:    userID,
+		OldRole:   user.Role,
+		NewRole:   req.Role,
+		ChangedBy: &adminID,
+		ChangedAt: time.Now().UTC(),
+	}
+	if user.Role != req.Role {
+		err = h.Users.SetUserRole(r.Context(), &change)
+		if errors.Is(err, store.ErrLastAdmin) {
+			http.Error(w, "Cannot demote the last admin", http.StatusConflict)
+			return
+		} else if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(change)
+}
+
+// GetRoleChanges lists recorded role changes
+// @Summary Get role change history (Admin Only)
+// @Description List every recorded role change, oldest first, optionally for a single user
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param user_id query int false "Only changes of this user"
+// @Success 200 {array} models.RoleChange
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden (Admins only)"
+// @Failure 500 {string} string "Server error"
+// @Router /admin/role-changes [get]
+func (h *AdminHandler) GetRoleChanges(w http.ResponseWriter, r *http.Request) {
+	var userID int
+	if raw := r.URL.Query().Get("user_id"); raw != "" {
+		var err error
+		userID, err = strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, "Invalid user_id", http.StatusBadRequest)
+			return
+		}
+	}
+	changes, err := h.Users.ListRoleChanges(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(changes)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/reminders"
+)
+
+// assignmentTimeout bounds the delivery of one assignment notification.
+const assignmentTimeout = 30 * time.Second
+
+// checkAssignee checks that the assignee of todo, when set, is its owner or
+// was granted a share of it, writing a 400 when not. A new todo is covered
+// by the shares of its parent or of all its owner's todos.
+func (h *TodoHandler) checkAssignee(w http.ResponseWriter, r *http.Request, todo models.Todo) bool {
+	if todo.AssigneeID == nil || *todo.AssigneeID == todo.UserId {
+		return true
+	}
+	assigneeID := *todo.AssigneeID
+	var role string
+	var err error
+	switch {
+	case todo.ID != 0:
+		role, err = h.Shares.ShareRole(r.Context(), todo.ID, assigneeID)
+	case todo.ParentID != nil:
+		role, err = h.Shares.ShareRole(r.Context(), *todo.ParentID, assigneeID)
+	default:
+		var shares []models.Share
+		shares, err = h.Shares.ListShares(r.Context(), todo.UserId)
+		for _, share := range shares {
+			if share.OwnerID == todo.UserId && share.GranteeID == assigneeID && share.TodoID == nil {
+				role = share.Role
+			}
+		}
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return false
+	}
+	if role == "" {
+		http.Error(w, "Assignee does not have access to this todo", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// notifyAssigned tells the assignee of todo, when assignedBy just assigned it
+// to someone else, in the background. Failures are only logged.
+func (h *TodoHandler) notifyAssigned(existing, todo models.Todo, assignedBy int) {
+	if h.Assignments == nil || todo.AssigneeID == nil || *todo.AssigneeID == assignedBy || sameID(existing.AssigneeID, todo.AssigneeID) {
+		return
+	}
+	assignment := reminders.Assignment{
+		TodoID:     todo.ID,
+		Title:      todo.Title,
+		AssigneeID: *todo.AssigneeID,
+		AssignedBy: assignedBy,
+		AssignedAt: time.Now().UTC(),
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), assignmentTimeout)
+		defer cancel()
+		if err := h.Assignments.NotifyAssignment(ctx, assignment); err != nil {
+			log.Printf("Failed to notify user %d of todo %d: %v", assignment.AssigneeID, assignment.TodoID, err)
+		}
+	}()
+}
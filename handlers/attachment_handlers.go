@@ -0,0 +1,360 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Anwarjondev/todo-api-go/blobs"
+	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/store"
+)
+
+const (
+	// maxAttachmentSize caps the size of one uploaded file.
+	maxAttachmentSize = 10 << 20
+	// maxUploadOverhead is what the rest of an upload request may add to the
+	// file: the multipart boundaries and headers and any other form fields.
+	maxUploadOverhead = 1 << 20
+	maxFilenameLength = 255
+)
+
+// attachmentTypes lists the media types an uploaded file may have, as
+// detected from its contents rather than taken from the client.
+var attachmentTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"text/plain":      true,
+}
+
+// AttachmentHandler serves the endpoints for files attached to todos.
+type AttachmentHandler struct {
+	Attachments store.AttachmentStore
+	Todos       store.TodoStore
+	Shares      store.ShareStore
+	Blobs       blobs.BlobStore
+}
+
+func NewAttachmentHandler(attachments store.AttachmentStore, todos store.TodoStore, shares store.ShareStore, blobs blobs.BlobStore) *AttachmentHandler {
+	return &AttachmentHandler{Attachments: attachments, Todos: todos, Shares: shares, Blobs: blobs}
+}
+
+// GetAttachments lists the files attached to a todo
+// @Summary Get Attachments
+// @Description List the files attached to a todo (users can see their own todos and those shared with them, admins can see any)
+// @Tags Attachments
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Success 200 {array} models.Attachment
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Todo not found"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id}/attachments [get]
+func (h *AttachmentHandler) GetAttachments(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	role := r.Context().Value("role").(string)
+
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := readableTodo(w, r, h.Todos, h.Shares, id, userID, role); !ok {
+		return
+	}
+	attachments, err := h.Attachments.ListAttachments(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if attachments == nil {
+		attachments = []models.Attachment{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(attachments)
+}
+
+// UploadAttachment attaches a file to a todo
+// @Summary Upload Attachment
+// @Description Attach a file of up to 10 MB to a todo the current user owns or can edit. The type is detected from the contents and must be PNG, JPEG, GIF, WebP, PDF or plain text.
+// @Tags Attachments
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param file formData file true "File to attach"
+// @Success 201 {object} models.Attachment
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 413 {string} string "File too large"
+// @Failure 415 {string} string "Unsupported file type"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id}/attachments [post]
+func (h *AttachmentHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := editableTodo(w, r, h.Todos, h.Shares, id, userID); !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxAttachmentSize+maxUploadOverhead)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Content-Type must be multipart/form-data", http.StatusUnsupportedMediaType)
+		return
+	}
+	var part io.Reader
+	var filename string
+	for {
+		p, err := reader.NextPart()
+		if err == io.EOF {
+			http.Error(w, "file is required", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			uploadError(w, err)
+			return
+		}
+		if p.FormName() == "file" {
+			part, filename = p, cleanFilename(p.FileName())
+			break
+		}
+	}
+	if filename == "" {
+		http.Error(w, "file needs a filename", http.StatusBadRequest)
+		return
+	}
+
+	// Sniff the type from the first bytes, then stream them back in front
+	// of the rest of the file.
+	head := make([]byte, 512)
+	n, err := io.ReadFull(part, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		uploadError(w, err)
+		return
+	}
+	if n == 0 {
+		http.Error(w, "file is empty", http.StatusBadRequest)
+		return
+	}
+	contentType := http.DetectContentType(head[:n])
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if !attachmentTypes[mediaType] {
+		http.Error(w, fmt.Sprintf("File type %s is not allowed", mediaType), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// Read one byte past the limit to tell a file of exactly the maximum
+	// size from a larger one.
+	body := io.LimitReader(io.MultiReader(bytes.NewReader(head[:n]), part), maxAttachmentSize+1)
+	key, size, err := h.Blobs.Put(r.Context(), body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "File is larger than 10 MB", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Error storing file", http.StatusInternalServerError)
+		return
+	}
+	if size > maxAttachmentSize {
+		deleteBlob(r.Context(), h.Blobs, key)
+		http.Error(w, "File is larger than 10 MB", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	attachment := models.Attachment{
+		TodoID:      id,
+		UserID:      userID,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        size,
+		BlobKey:     key,
+		CreatedAt:   time.Now(),
+	}
+	if err := h.Attachments.CreateAttachment(r.Context(), &attachment); err != nil {
+		// The todo may have been deleted while the file was uploading.
+		deleteBlob(r.Context(), h.Blobs, key)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/todos/%d/attachments/%d", id, attachment.ID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(attachment)
+}
+
+// DownloadAttachment streams an attached file
+// @Summary Download Attachment
+// @Description Download a file attached to a todo. Range requests are supported for resuming and partial downloads.
+// @Tags Attachments
+// @Security BearerAuth
+// @Produce octet-stream
+// @Param id path int true "Todo ID"
+// @Param attachmentID path int true "Attachment ID"
+// @Param Range header string false "Byte range, e.g. bytes=0-1023"
+// @Success 200 {file} file "File contents"
+// @Success 206 {file} file "Requested range of the file"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Attachment not found"
+// @Failure 416 {string} string "Range not satisfiable"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id}/attachments/{attachmentID} [get]
+func (h *AttachmentHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	role := r.Context().Value("role").(string)
+
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := readableTodo(w, r, h.Todos, h.Shares, id, userID, role); !ok {
+		return
+	}
+	attachment, ok := h.todoAttachment(w, r, id)
+	if !ok {
+		return
+	}
+	f, err := h.Blobs.Open(r.Context(), attachment.BlobKey)
+	if errors.Is(err, blobs.ErrNotFound) {
+		log.Printf("Blob of attachment %d is missing", attachment.ID)
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Error reading file", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	w.Header().Set("Content-Disposition", disposition)
+	http.ServeContent(w, r, attachment.Filename, attachment.CreatedAt, f)
+}
+
+// DeleteAttachment removes a file from a todo
+// @Summary Delete Attachment
+// @Description Delete a file attached to a todo the current user owns or can edit
+// @Tags Attachments
+// @Security BearerAuth
+// @Param id path int true "Todo ID"
+// @Param attachmentID path int true "Attachment ID"
+// @Success 204 {string} string "Attachment deleted"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Attachment not found"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id}/attachments/{attachmentID} [delete]
+func (h *AttachmentHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := editableTodo(w, r, h.Todos, h.Shares, id, userID); !ok {
+		return
+	}
+	attachment, ok := h.todoAttachment(w, r, id)
+	if !ok {
+		return
+	}
+	err := h.Attachments.DeleteAttachment(r.Context(), attachment.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	deleteBlob(r.Context(), h.Blobs, attachment.BlobKey)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// todoAttachment loads the attachment named by the {attachmentID} path value,
+// writing a 404 unless it is attached to todoID.
+func (h *AttachmentHandler) todoAttachment(w http.ResponseWriter, r *http.Request, todoID int) (models.Attachment, bool) {
+	id, err := strconv.Atoi(r.PathValue("attachmentID"))
+	if err != nil {
+		http.Error(w, "Invalid attachment id", http.StatusBadRequest)
+		return models.Attachment{}, false
+	}
+	attachment, err := h.Attachments.GetAttachment(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && attachment.TodoID != todoID) {
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+		return models.Attachment{}, false
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return models.Attachment{}, false
+	}
+	return attachment, true
+}
+
+// deleteBlob removes a blob that no attachment refers to any more. A failure
+// only leaves an orphaned file behind, so it is logged rather than returned.
+func deleteBlob(ctx context.Context, files blobs.BlobStore, key string) {
+	if err := files.Delete(ctx, key); err != nil {
+		log.Printf("Failed to delete blob %s: %v", key, err)
+	}
+}
+
+// uploadError writes the response for an error reading an upload request.
+func uploadError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, "File is larger than 10 MB", http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, "Invalid multipart body", http.StatusBadRequest)
+}
+
+// cleanFilename reduces a client-supplied file name to something safe to
+// store and send back in a Content-Disposition header: no directories, no
+// control characters and at most maxFilenameLength bytes.
+func cleanFilename(name string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	for len(name) > maxFilenameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "." || name == ".." {
+		return ""
+	}
+	return name
+}
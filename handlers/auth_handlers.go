@@ -1,15 +1,19 @@
 package handlers
 
 import (
-	"database/sql"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"os"
 	"time"
 
-	"github.com/Anwarjondev/todo-api-go/db"
 	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/store"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/joho/godotenv"
 	"golang.org/x/crypto/bcrypt"
@@ -17,6 +21,11 @@ import (
 
 var jwtkey []byte
 
+const (
+	accessTokenTTL  = 30 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
 func init() {
 	if os.Getenv("RAILWAY_ENVIRONMENT") == "" {
 		if err := godotenv.Load(); err != nil {
@@ -37,9 +46,19 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
+// AuthHandler serves registration, login and token refresh.
+type AuthHandler struct {
+	Users  store.UserStore
+	Tokens store.RefreshTokenStore
+}
+
+func NewAuthHandler(users store.UserStore, tokens store.RefreshTokenStore) *AuthHandler {
+	return &AuthHandler{Users: users, Tokens: tokens}
+}
+
 // Register a new user
 // @Summary Register User
-// @Description Register a new user (default role: user)
+// @Description Register a new user. New accounts always get the user role; admins are created by promotion.
 // @Tags Authentication
 // @Accept json
 // @Produce json
@@ -48,26 +67,31 @@ type Claims struct {
 // @Failure 400 {string} string "Invalid request"
 // @Failure 500 {string} string "Server error"
 // @Router /register [post]
-func Register(w http.ResponseWriter, r *http.Request) {
-	var user models.User
-	err := json.NewDecoder(r.Body).Decode(&user)
+func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req models.UserModel
+	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
-	if user.Role == "" {
-		user.Role = "user"
+	if req.Username == "" || req.Password == "" {
+		http.Error(w, "Username and password are required", http.StatusBadRequest)
+		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	// Any role sent by the client is ignored.
+	user, err := NewUser(req.Username, req.Password, "user")
 	if err != nil {
 		http.Error(w, "Error with hashing password", http.StatusInternalServerError)
 		return
 	}
-	_, err = db.DB.Exec("Insert into users(username, password, role) values($1, $2, $3)", user.Username, string(hashedPassword), user.Role)
-	if err != nil {
+	err = h.Users.CreateUser(r.Context(), &user)
+	if errors.Is(err, store.ErrUsernameTaken) {
 		http.Error(w, "Username already taken", http.StatusBadRequest)
 		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
 	}
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{"message": "User create registered successfuly"})
@@ -75,44 +99,150 @@ func Register(w http.ResponseWriter, r *http.Request) {
 
 // Login and generate JWT
 // @Summary User Login
-// @Description Login user and receive JWT token
+// @Description Login user and receive a 30-minute JWT access token and a long-lived refresh token
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Param user body models.UserModel true "User Credentials"
-// @Success 200 {object} map[string]string
+// @Success 200 {object} models.TokenResponse
 // @Failure 400 {string} string "Invalid request"
 // @Failure 401 {string} string "Invalid credentials"
 // @Router /login [post]
-func Login(w http.ResponseWriter, r *http.Request) {
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var user models.User
 	err := json.NewDecoder(r.Body).Decode(&user)
 	if err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
-	var storedPassword string
-	var userId int
-	var userRole string
-	err = db.DB.QueryRow("Select id, password, role from users where username = $1", user.Username).Scan(&userId, &storedPassword, &userRole)
-	if err == sql.ErrNoRows {
+	stored, err := h.Users.GetUserByUsername(r.Context(), user.Username)
+	if errors.Is(err, store.ErrNotFound) {
 		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
 		return
 	} else if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
-	err = bcrypt.CompareHashAndPassword([]byte(storedPassword), []byte(user.Password))
+	err = bcrypt.CompareHashAndPassword([]byte(stored.Password), []byte(user.Password))
 	if err != nil {
 		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
 		return
 	}
-	expritionTime := time.Now().Add(30 * time.Minute)
+	familyID, err := randomToken()
+	if err != nil {
+		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+	h.writeTokens(w, r, stored, familyID)
+}
+
+// RefreshToken exchanges a refresh token for new tokens
+// @Summary Refresh Token
+// @Description Exchange a refresh token for a new access token and a new refresh token. Each refresh token can be used once; presenting a used token again revokes every token issued from the same login.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param token body models.RefreshTokenModel true "Refresh token"
+// @Success 200 {object} models.TokenResponse
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Invalid refresh token"
+// @Router /token/refresh [post]
+func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshTokenModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	token, err := h.Tokens.GetRefreshToken(r.Context(), hashToken(req.RefreshToken))
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	now := time.Now()
+	if token.RevokedAt != nil || now.After(token.ExpiresAt) {
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+
+	// A token that was already rotated is being replayed, so it may have been
+	// stolen: revoke the whole family, forcing the user to log in again.
+	err = h.Tokens.UseRefreshToken(r.Context(), token.ID, now)
+	if errors.Is(err, store.ErrTokenUsed) {
+		if err := h.Tokens.RevokeTokenFamily(r.Context(), token.FamilyID, now); err != nil {
+			log.Printf("revoke refresh token family %s: %v", token.FamilyID, err)
+		}
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	user, err := h.Users.GetUser(r.Context(), token.UserID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	h.writeTokens(w, r, user, token.FamilyID)
+}
+
+// Logout revokes a refresh token
+// @Summary Logout
+// @Description Revoke a refresh token and every token rotated from the same login
+// @Tags Authentication
+// @Accept json
+// @Param token body models.RefreshTokenModel true "Refresh token"
+// @Success 204 {string} string "Logged out"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 500 {string} string "Server error"
+// @Router /logout [post]
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshTokenModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	token, err := h.Tokens.GetRefreshToken(r.Context(), hashToken(req.RefreshToken))
+	if errors.Is(err, store.ErrNotFound) {
+		// Unknown tokens are already as logged out as they can be.
+		w.WriteHeader(http.StatusNoContent)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.Tokens.RevokeTokenFamily(r.Context(), token.FamilyID, time.Now()); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// NewUser returns a user with the given role and a bcrypt hash of password,
+// ready to be passed to UserStore.CreateUser.
+func NewUser(username, password, role string) (models.User, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, err
+	}
+	return models.User{Username: username, Password: string(hashedPassword), Role: role}, nil
+}
+
+// writeTokens issues a new access token and a new refresh token in familyID
+// for user and writes them as the response.
+func (h *AuthHandler) writeTokens(w http.ResponseWriter, r *http.Request, user models.User, familyID string) {
+	now := time.Now()
 	claims := &Claims{
-		UserId: userId,
-		Role:   userRole,
+		UserId: user.ID,
+		Role:   user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expritionTime),
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTokenTTL)),
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -121,6 +251,45 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
 		return
 	}
+
+	refresh, err := randomToken()
+	if err != nil {
+		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+	err = h.Tokens.CreateRefreshToken(r.Context(), &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refresh),
+		CreatedAt: now,
+		ExpiresAt: now.Add(refreshTokenTTL),
+	})
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"token": tokenString})
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(models.TokenResponse{
+		Token:        tokenString,
+		RefreshToken: refresh,
+		ExpiresIn:    int(accessTokenTTL.Seconds()),
+	})
+}
+
+// randomToken returns 32 random bytes encoded as unpadded base64url.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex SHA-256 of a refresh token, which is what the
+// database stores in place of the token itself.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/markdown"
+	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/store"
+)
+
+const maxCommentLength = 10000
+
+// CommentHandler serves the endpoints for comments on todos. Comments can be
+// read by whoever may read the todo and written by whoever may change it.
+type CommentHandler struct {
+	Comments store.CommentStore
+	Todos    store.TodoStore
+	Shares   store.ShareStore
+}
+
+func NewCommentHandler(comments store.CommentStore, todos store.TodoStore, shares store.ShareStore) *CommentHandler {
+	return &CommentHandler{Comments: comments, Todos: todos, Shares: shares}
+}
+
+// GetComments lists the comments on a todo
+// @Summary Get Comments
+// @Description List the comments on a todo, oldest first (users can see their own todos and those shared with them, admins can see any). body_html holds the Markdown body rendered to sanitized HTML.
+// @Tags Comments
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Success 200 {array} models.Comment
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id}/comments [get]
+func (h *CommentHandler) GetComments(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	role := r.Context().Value("role").(string)
+
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := readableTodo(w, r, h.Todos, h.Shares, id, userID, role); !ok {
+		return
+	}
+	comments, err := h.Comments.ListComments(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if comments == nil {
+		comments = []models.Comment{}
+	}
+	for i := range comments {
+		renderComment(&comments[i])
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comments)
+}
+
+// CreateComment adds a comment to a todo
+// @Summary Create Comment
+// @Description Comment on a todo the current user owns or can edit. The body is Markdown of up to 10000 characters.
+// @Tags Comments
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param comment body models.CommentModel true "Comment body"
+// @Success 201 {object} models.Comment
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id}/comments [post]
+func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	body, ok := commentBody(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := editableTodo(w, r, h.Todos, h.Shares, id, userID); !ok {
+		return
+	}
+	comment := models.Comment{TodoID: id, UserID: userID, Body: body, CreatedAt: time.Now()}
+	if err := h.Comments.CreateComment(r.Context(), &comment); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	renderComment(&comment)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/todos/%d/comments/%d", id, comment.ID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(comment)
+}
+
+// UpdateComment edits a comment
+// @Summary Update Comment
+// @Description Replace the body of a comment. Only its author can edit it.
+// @Tags Comments
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param commentID path int true "Comment ID"
+// @Param comment body models.CommentModel true "New comment body"
+// @Success 200 {object} models.Comment
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Comment not found"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id}/comments/{commentID} [put]
+func (h *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	body, ok := commentBody(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := editableTodo(w, r, h.Todos, h.Shares, id, userID); !ok {
+		return
+	}
+	comment, ok := h.todoComment(w, r, id)
+	if !ok {
+		return
+	}
+	if comment.UserID != userID {
+		http.Error(w, "Only the author can edit a comment", http.StatusForbidden)
+		return
+	}
+	comment.Body = body
+	comment.UpdatedAt = time.Now()
+	err := h.Comments.UpdateComment(r.Context(), &comment)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	renderComment(&comment)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comment)
+}
+
+// DeleteComment deletes a comment
+// @Summary Delete Comment
+// @Description Delete a comment. The author and the owner of the todo can delete it.
+// @Tags Comments
+// @Security BearerAuth
+// @Param id path int true "Todo ID"
+// @Param commentID path int true "Comment ID"
+// @Success 204 {string} string "Comment deleted"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Comment not found"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id}/comments/{commentID} [delete]
+func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	todo, ok := editableTodo(w, r, h.Todos, h.Shares, id, userID)
+	if !ok {
+		return
+	}
+	comment, ok := h.todoComment(w, r, id)
+	if !ok {
+		return
+	}
+	if comment.UserID != userID && todo.UserId != userID {
+		http.Error(w, "Only the author or the owner of the todo can delete a comment", http.StatusForbidden)
+		return
+	}
+	err := h.Comments.DeleteComment(r.Context(), comment.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// todoComment loads the comment named by the {commentID} path value, writing
+// a 404 unless it is on todoID.
+func (h *CommentHandler) todoComment(w http.ResponseWriter, r *http.Request, todoID int) (models.Comment, bool) {
+	id, err := strconv.Atoi(r.PathValue("commentID"))
+	if err != nil {
+		http.Error(w, "Invalid comment id", http.StatusBadRequest)
+		return models.Comment{}, false
+	}
+	comment, err := h.Comments.GetComment(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && comment.TodoID != todoID) {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return models.Comment{}, false
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return models.Comment{}, false
+	}
+	return comment, true
+}
+
+// commentBody decodes and validates the body of a comment request.
+func commentBody(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req models.CommentModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return "", false
+	}
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		http.Error(w, "Body is required", http.StatusBadRequest)
+		return "", false
+	}
+	if len(body) > maxCommentLength {
+		http.Error(w, "Body is too long", http.StatusBadRequest)
+		return "", false
+	}
+	return body, true
+}
+
+// renderComment fills in the sanitized HTML form of the comment's body.
+func renderComment(comment *models.Comment) {
+	comment.BodyHTML = markdown.Render(comment.Body)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/store"
+)
+
+// DependencyHandler serves the endpoints that record which todos block
+// which. A todo can only be blocked by todos of the same owner.
+type DependencyHandler struct {
+	Dependencies store.DependencyStore
+	Todos        store.TodoStore
+	Shares       store.ShareStore
+}
+
+func NewDependencyHandler(dependencies store.DependencyStore, todos store.TodoStore, shares store.ShareStore) *DependencyHandler {
+	return &DependencyHandler{Dependencies: dependencies, Todos: todos, Shares: shares}
+}
+
+// AddBlocker marks a todo as blocked by another
+// @Summary Add Blocker
+// @Description Record that a todo is blocked by another todo of the same owner until that one is completed. Dependencies cannot form cycles. Users can change their own todos and those shared with them as editor.
+// @Tags Todos
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param blockerID path int true "ID of the blocking todo"
+// @Success 200 {object} models.Todo
+// @Header 200 {string} ETag "The todo's new version"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 409 {string} string "The dependency would create a cycle"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id}/blockers/{blockerID} [put]
+func (h *DependencyHandler) AddBlocker(w http.ResponseWriter, r *http.Request) {
+	h.changeBlocker(w, r, h.Dependencies.AddDependency)
+}
+
+// RemoveBlocker removes a dependency
+// @Summary Remove Blocker
+// @Description Record that a todo is no longer blocked by another
+// @Tags Todos
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param blockerID path int true "ID of the blocking todo"
+// @Success 200 {object} models.Todo
+// @Header 200 {string} ETag "The todo's new version"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Todo is not blocked by that todo"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id}/blockers/{blockerID} [delete]
+func (h *DependencyHandler) RemoveBlocker(w http.ResponseWriter, r *http.Request) {
+	h.changeBlocker(w, r, h.Dependencies.RemoveDependency)
+}
+
+// changeBlocker checks that the current user may change the todo in the path
+// and that the blocker belongs to the same owner, applies change and
+// responds with the updated todo.
+func (h *DependencyHandler) changeBlocker(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, todoID, blockerID int) error) {
+	userID := r.Context().Value("user_id").(int)
+
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	blockerID, err := strconv.Atoi(r.PathValue("blockerID"))
+	if err != nil {
+		http.Error(w, "Invalid blocker id", http.StatusBadRequest)
+		return
+	}
+	todo, ok := editableTodo(w, r, h.Todos, h.Shares, id, userID)
+	if !ok {
+		return
+	}
+	blocker, err := h.Todos.GetTodo(r.Context(), blockerID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && blocker.UserId != todo.UserId) {
+		http.Error(w, "A todo can only be blocked by another todo of the same owner", http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	err = change(r.Context(), id, blocker.ID)
+	if errors.Is(err, store.ErrDependencyCycle) {
+		http.Error(w, "The dependency would create a cycle", http.StatusConflict)
+		return
+	} else if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Todo is not blocked by that todo", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if todo, err = h.Todos.GetTodo(r.Context(), id); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	setTodoETag(w, todo)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(todo)
+}
+
+// checkBlockers refuses to complete a blocked todo when the respect_blockers
+// query parameter is true. It writes an error response and returns false
+// when the edit is refused or the parameter is invalid.
+func checkBlockers(w http.ResponseWriter, r *http.Request, existing models.Todo, todo models.Todo) bool {
+	raw := r.URL.Query().Get("respect_blockers")
+	if raw == "" {
+		return true
+	}
+	respect, err := strconv.ParseBool(raw)
+	if err != nil {
+		http.Error(w, "respect_blockers must be true or false", http.StatusBadRequest)
+		return false
+	}
+	if respect && todo.Completed && !existing.Completed && existing.Blocked {
+		http.Error(w, "Todo is blocked by open todos", http.StatusConflict)
+		return false
+	}
+	return true
+}
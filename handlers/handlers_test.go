@@ -0,0 +1,7 @@
+package handlers
+
+import "os"
+
+// The package's init refuses to start without a JWT key. Package variables,
+// including this one, are initialized before it runs.
+var _ = os.Setenv("JWT_KEY", "test")
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+// GetHistory lists the changes made to a todo
+// @Summary Get Todo History
+// @Description List every recorded change to a todo, oldest first: who made it, when, the version it led to and each changed field's value before and after (users can see the history of their own todos and those shared with them, admins of any)
+// @Tags Todos
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Success 200 {array} models.HistoryEntry
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id}/history [get]
+func (h *TodoHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	role := r.Context().Value("role").(string)
+
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := readableTodo(w, r, h.Todos, h.Shares, id, userID, role); !ok {
+		return
+	}
+	entries, err := h.History.ListHistory(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []models.HistoryEntry{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// RevertTodo restores a todo to an earlier state
+// @Summary Revert Todo
+// @Description Put the fields a client can edit back to how they were right after the given history entry. The revert is a change of its own and is recorded in the history. The todo's position, tags and blockers are left alone. Users can revert their own todos and those shared with them as editor.
+// @Tags Todos
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param to query int true "ID of the history entry to go back to"
+// @Param scope query string false "For a recurring todo: change only this occurrence (default) or future ones too; reverting a change to the rrule or timezone needs future" Enums(this, future)
+// @Param If-Match header string false "ETag of the version the change is based on"
+// @Success 200 {object} models.Todo
+// @Header 200 {string} ETag "The todo's new version"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "History entry not found"
+// @Failure 409 {string} string "Occurrence already exists"
+// @Failure 412 {object} models.TodoConflict "The todo has changed since that version"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id}/revert [post]
+func (h *TodoHandler) RevertTodo(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	to, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil {
+		http.Error(w, "to must be the id of a history entry", http.StatusBadRequest)
+		return
+	}
+	future, ok := editScope(w, r)
+	if !ok {
+		return
+	}
+	existing, ok := editableTodo(w, r, h.Todos, h.Shares, id, userID)
+	if !ok || !checkIfMatch(w, r, existing) {
+		return
+	}
+	entries, err := h.History.ListHistory(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	patch, ok := revertPatch(entries, to)
+	if !ok {
+		http.Error(w, "History entry not found", http.StatusNotFound)
+		return
+	}
+	if len(patch) == 0 {
+		setTodoETag(w, existing)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(existing)
+		return
+	}
+	h.applyTodoPatch(w, r, existing, patch, future)
+}
+
+// revertPatch returns the merge patch that undoes every change recorded in
+// entries after the entry with the given id, or false when there is no such
+// entry. Only fields a client can patch are included.
+func revertPatch(entries []models.HistoryEntry, to int) (map[string]any, bool) {
+	at := -1
+	for i, entry := range entries {
+		if entry.ID == to {
+			at = i
+		}
+	}
+	if at < 0 {
+		return nil, false
+	}
+	patch := map[string]any{}
+	// Going from the newest entry back, each field ends up with its value
+	// from before the oldest change undone.
+	for i := len(entries) - 1; i > at; i-- {
+		for field, change := range entries[i].Changes {
+			if !todoPatchFields[field] {
+				continue
+			}
+			dec := json.NewDecoder(bytes.NewReader(change.From))
+			dec.UseNumber()
+			var value any
+			if err := dec.Decode(&value); err != nil {
+				continue
+			}
+			patch[field] = value
+		}
+	}
+	return patch, true
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import "net/http"
+
+// The handlers below keep the original verb-shaped routes working. They take
+// the todo id from the "id" query parameter and are served with a Deprecation
+// header pointing at their /todos/{id} replacement.
+
+// CreateTodoLegacy creates a new todo
+// @Summary Create Todo (deprecated)
+// @Description Deprecated alias of POST /todos
+// @Tags Todos
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param todo body models.TodoModel true "Todo data"
+// @Success 201 {object} models.Todo
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Server error"
+// @Deprecated
+// @Router /todos/create [post]
+func (h *TodoHandler) CreateTodoLegacy(w http.ResponseWriter, r *http.Request) {
+	h.CreateTodo(w, r)
+}
+
+// UpdateTodoLegacy updates an existing todo
+// @Summary Update Todo (deprecated)
+// @Description Deprecated alias of PUT /todos/{id}
+// @Tags Todos
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param todo body models.UpdateTodoModel true "Updated todo data"
+// @Param id query int true "Todo ID"
+// @Param If-Match header string false "ETag of the version the change is based on"
+// @Success 200 {object} models.Todo
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 412 {object} models.TodoConflict "The todo has changed since that version"
+// @Failure 500 {string} string "Server error"
+// @Deprecated
+// @Router /todos/update [put]
+func (h *TodoHandler) UpdateTodoLegacy(w http.ResponseWriter, r *http.Request) {
+	h.UpdateTodo(w, r)
+}
+
+// DeleteTodoLegacy deletes a todo
+// @Summary Delete Todo (deprecated)
+// @Description Deprecated alias of DELETE /todos/{id}
+// @Tags Todos
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id query int true "Todo ID"
+// @Success 204 {string} string "Todo deleted"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 500 {string} string "Server error"
+// @Deprecated
+// @Router /todos/delete [delete]
+func (h *TodoHandler) DeleteTodoLegacy(w http.ResponseWriter, r *http.Request) {
+	h.DeleteTodo(w, r)
+}
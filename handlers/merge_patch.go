@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// MergePatchContentType is the media type of an RFC 7396 JSON Merge Patch.
+const MergePatchContentType = "application/merge-patch+json"
+
+// todoPatchFields lists the todo fields a client may change with PATCH.
+var todoPatchFields = map[string]bool{
+	"title":       true,
+	"completed":   true,
+	"status_id":   true,
+	"priority":    true,
+	"assignee_id": true,
+	"project_id":  true,
+	"parent_id":   true,
+	"due_at":      true,
+	"remind_at":   true,
+	"rrule":       true,
+	"timezone":    true,
+}
+
+// decodeMergePatch reads a JSON Merge Patch document from the request body.
+// Only object patches are accepted, and every top-level member must be one of
+// allowed.
+func decodeMergePatch(r *http.Request, allowed map[string]bool) (map[string]any, error) {
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || (mediaType != MergePatchContentType && mediaType != "application/json") {
+			return nil, errUnsupportedMediaType
+		}
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+	var patch any
+	if err := dec.Decode(&patch); err != nil {
+		return nil, errors.New("invalid JSON")
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after JSON document")
+	}
+	obj, ok := patch.(map[string]any)
+	if !ok {
+		return nil, errors.New("patch must be a JSON object")
+	}
+	var unknown []string
+	for field := range obj {
+		if !allowed[field] {
+			unknown = append(unknown, field)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("cannot patch field(s): %s", strings.Join(unknown, ", "))
+	}
+	return obj, nil
+}
+
+var errUnsupportedMediaType = errors.New("Content-Type must be " + MergePatchContentType)
+
+// applyMergePatch applies patch to the JSON form of v and decodes the result
+// back into v, following RFC 7396: members set to null are removed (so the
+// field falls back to its zero value) and objects are merged recursively.
+func applyMergePatch[T any](v *T, patch map[string]any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var target any
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&target); err != nil {
+		return err
+	}
+	merged, err := json.Marshal(mergePatch(target, patch))
+	if err != nil {
+		return err
+	}
+	var result T
+	if err := json.Unmarshal(merged, &result); err != nil {
+		return err
+	}
+	*v = result
+	return nil
+}
+
+// mergePatch is the MergePatch function from RFC 7396, section 2.
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for name, value := range p {
+		if value == nil {
+			delete(t, name)
+		} else {
+			t[name] = mergePatch(t[name], value)
+		}
+	}
+	return t
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/store"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+var todoSorts = map[string]store.TodoSort{
+	"rank":    store.SortByRank,
+	"id":      store.SortByID,
+	"title":   store.SortByTitle,
+	"created": store.SortByCreated,
+	"due":     store.SortByDue,
+}
+
+// todoCursor is the decoded form of the opaque cursor returned as
+// next_cursor: the order of the listing and the position of the last todo
+// on the page.
+type todoCursor struct {
+	Sort    store.TodoSort `json:"s"`
+	Desc    bool           `json:"d,omitempty"`
+	ID      int            `json:"id"`
+	Rank    string         `json:"r,omitempty"`
+	Title   string         `json:"t,omitempty"`
+	Created *time.Time     `json:"c,omitempty"`
+	Due     *time.Time     `json:"due,omitempty"`
+}
+
+// parseTodoPage sets the sort order, page size and starting point given by
+// the sort, order, limit and cursor query parameters on filter, writing a
+// 400 and returning false when one of them is invalid. A cursor carries its
+// own order, so sort and order may be left out when it is given.
+func parseTodoPage(w http.ResponseWriter, r *http.Request, filter *store.TodoFilter) bool {
+	query := r.URL.Query()
+
+	filter.Sort = store.SortByRank
+	if raw := query.Get("sort"); raw != "" {
+		sort, ok := todoSorts[raw]
+		if !ok {
+			http.Error(w, "sort must be rank, id, title, created or due", http.StatusBadRequest)
+			return false
+		}
+		filter.Sort = sort
+	}
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		filter.Desc = true
+	default:
+		http.Error(w, "order must be asc or desc", http.StatusBadRequest)
+		return false
+	}
+
+	filter.Limit = defaultPageSize
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			http.Error(w, "limit must be between 1 and "+strconv.Itoa(maxPageSize), http.StatusBadRequest)
+			return false
+		}
+		filter.Limit = limit
+	}
+
+	if raw := query.Get("cursor"); raw != "" {
+		cursor, ok := decodeCursor(raw)
+		if !ok {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return false
+		}
+		if (query.Has("sort") && cursor.Sort != filter.Sort) || (query.Has("order") && cursor.Desc != filter.Desc) {
+			http.Error(w, "Cursor was issued for a different sort order", http.StatusBadRequest)
+			return false
+		}
+		filter.Sort, filter.Desc = cursor.Sort, cursor.Desc
+		filter.After = &models.Todo{ID: cursor.ID, Rank: cursor.Rank, Title: cursor.Title, DueAt: cursor.Due}
+		if cursor.Created != nil {
+			filter.After.CreatedAt = *cursor.Created
+		}
+	}
+	return true
+}
+
+// writeTodoPage lists one page of the todos matching filter, as set up by
+// parseTodoFilter and parseTodoPage, and writes it as a models.TodoPage.
+func writeTodoPage(w http.ResponseWriter, r *http.Request, todos store.TodoStore, filter store.TodoFilter) {
+	limit := filter.Limit
+	// Fetch one extra todo to learn whether there is another page.
+	filter.Limit++
+	list, err := todos.ListTodos(r.Context(), filter)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	page := models.TodoPage{Todos: list}
+	if len(list) > limit {
+		page.Todos = list[:limit]
+		page.NextCursor = encodeCursor(filter, page.Todos[limit-1])
+	}
+	if page.Todos == nil {
+		page.Todos = []models.Todo{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+func encodeCursor(filter store.TodoFilter, last models.Todo) string {
+	cursor := todoCursor{Sort: filter.Sort, Desc: filter.Desc, ID: last.ID}
+	switch filter.Sort {
+	case store.SortByRank:
+		cursor.Rank = last.Rank
+	case store.SortByTitle:
+		cursor.Title = last.Title
+	case store.SortByCreated:
+		cursor.Created = &last.CreatedAt
+	case store.SortByDue:
+		cursor.Due = last.DueAt
+	}
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (todoCursor, bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return todoCursor{}, false
+	}
+	var cursor todoCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID <= 0 {
+		return todoCursor{}, false
+	}
+	for _, sort := range todoSorts {
+		if cursor.Sort == sort {
+			return cursor, true
+		}
+	}
+	return todoCursor{}, false
+}
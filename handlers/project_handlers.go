@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/store"
+)
+
+const maxProjectNameLength = 100
+
+// projectColor matches the #RRGGBB colors a project may carry.
+var projectColor = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// projectPatchFields lists the project fields a client may change with PATCH.
+var projectPatchFields = map[string]bool{
+	"name":     true,
+	"color":    true,
+	"archived": true,
+	"position": true,
+}
+
+// ProjectHandler serves the project endpoints.
+type ProjectHandler struct {
+	Projects store.ProjectStore
+	Todos    store.TodoStore
+}
+
+func NewProjectHandler(projects store.ProjectStore, todos store.TodoStore) *ProjectHandler {
+	return &ProjectHandler{Projects: projects, Todos: todos}
+}
+
+// GetProjects lists the current user's projects
+// @Summary Get Projects
+// @Description List the current user's projects ordered by position. Archived projects are left out unless include_archived=true.
+// @Tags Projects
+// @Security BearerAuth
+// @Produce json
+// @Param include_archived query bool false "Include archived projects"
+// @Success 200 {array} models.Project
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Server error"
+// @Router /projects [get]
+func (h *ProjectHandler) GetProjects(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	includeArchived := r.URL.Query().Get("include_archived") == "true"
+	projects, err := h.Projects.ListProjects(r.Context(), userID, includeArchived)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(projects)
+}
+
+// GetProject returns a single project
+// @Summary Get Project
+// @Description Get one of the current user's projects
+// @Tags Projects
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Project ID"
+// @Success 200 {object} models.Project
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Project not found"
+// @Failure 500 {string} string "Server error"
+// @Router /projects/{id} [get]
+func (h *ProjectHandler) GetProject(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	project, ok := h.ownProject(w, r, userID)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(project)
+}
+
+// CreateProject creates a project
+// @Summary Create Project
+// @Description Create a project for the current user
+// @Tags Projects
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param project body models.ProjectModel true "Project data"
+// @Success 201 {object} models.Project
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Server error"
+// @Router /projects [post]
+func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	var req models.ProjectModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	project := models.Project{
+		UserID:   userID,
+		Name:     strings.TrimSpace(req.Name),
+		Color:    req.Color,
+		Position: req.Position,
+	}
+	if err := validateProject(project); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.Projects.CreateProject(r.Context(), &project); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/projects/"+strconv.Itoa(project.ID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(project)
+}
+
+// PatchProject partially updates a project
+// @Summary Patch Project
+// @Description Change only the supplied fields of a project using a JSON Merge Patch (RFC 7396). The Inbox cannot be archived.
+// @Tags Projects
+// @Security BearerAuth
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Param patch body models.PatchProjectModel true "Fields to change"
+// @Success 200 {object} models.Project
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Project not found"
+// @Failure 415 {string} string "Unsupported media type"
+// @Failure 500 {string} string "Server error"
+// @Router /projects/{id} [patch]
+func (h *ProjectHandler) PatchProject(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	patch, err := decodeMergePatch(r, projectPatchFields)
+	if errors.Is(err, errUnsupportedMediaType) {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	} else if err != nil {
+		http.Error(w, "Invalid request payload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	existing, ok := h.ownProject(w, r, userID)
+	if !ok {
+		return
+	}
+
+	project := existing
+	if err := applyMergePatch(&project, patch); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	project.ID = existing.ID
+	project.UserID = existing.UserID
+	project.IsInbox = existing.IsInbox
+	project.Name = strings.TrimSpace(project.Name)
+	if err := validateProject(project); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if project.IsInbox && project.Archived {
+		http.Error(w, "The Inbox cannot be archived", http.StatusBadRequest)
+		return
+	}
+	if err := h.Projects.UpdateProject(r.Context(), &project); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(project)
+}
+
+// DeleteProject deletes a project
+// @Summary Delete Project
+// @Description Delete one of the current user's projects. Its todos are moved to the Inbox, which itself cannot be deleted.
+// @Tags Projects
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Success 204 {string} string "Project deleted"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Project not found"
+// @Failure 409 {string} string "The Inbox cannot be deleted"
+// @Failure 500 {string} string "Server error"
+// @Router /projects/{id} [delete]
+func (h *ProjectHandler) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	project, ok := h.ownProject(w, r, userID)
+	if !ok {
+		return
+	}
+	err := h.Projects.DeleteProject(r.Context(), project.ID)
+	if errors.Is(err, store.ErrInboxProject) {
+		http.Error(w, "The Inbox cannot be deleted", http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetProjectTodos lists the todos in a project
+// @Summary Get Project Todos
+// @Description List the todos in one of the current user's projects. Accepts the same filters and paging as GET /todos.
+// @Tags Projects
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Project ID"
+// @Param completed query bool false "Filter by completion state"
+// @Param assignee query string false "Only todos assigned to this user id, or to the current user with me"
+// @Param due query string false "overdue, today or upcoming"
+// @Param tz query string false "IANA time zone used for the due filter"
+// @Param tag query []string false "Only todos with these tags" collectionFormat(multi)
+// @Param tag_match query string false "all (default) or any"
+// @Param sort query string false "Sort field (default rank, the order set with POST /todos/{id}/move)" Enums(rank, id, title, created, due)
+// @Param order query string false "Sort direction (default asc)" Enums(asc, desc)
+// @Param limit query int false "Page size, 1 to 200 (default 50)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} models.TodoPage
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Project not found"
+// @Failure 500 {string} string "Server error"
+// @Router /projects/{id}/todos [get]
+func (h *ProjectHandler) GetProjectTodos(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	project, ok := h.ownProject(w, r, userID)
+	if !ok {
+		return
+	}
+	filter := store.TodoFilter{UserID: userID}
+	if !parseTodoFilter(w, r, &filter) || !parseTodoPage(w, r, &filter) {
+		return
+	}
+	filter.ProjectID = project.ID
+	writeTodoPage(w, r, h.Todos, filter)
+}
+
+// ownProject loads the project named by the {id} path value and checks that
+// it belongs to userID, writing a 404 when it does not exist or belongs to
+// someone else.
+func (h *ProjectHandler) ownProject(w http.ResponseWriter, r *http.Request, userID int) (models.Project, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid project id", http.StatusBadRequest)
+		return models.Project{}, false
+	}
+	project, err := h.Projects.GetProject(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && project.UserID != userID) {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return models.Project{}, false
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return models.Project{}, false
+	}
+	return project, true
+}
+
+// validateProject checks the client-controlled fields of a project.
+func validateProject(project models.Project) error {
+	if project.Name == "" {
+		return errors.New("Name is required")
+	}
+	if len(project.Name) > maxProjectNameLength {
+		return errors.New("Name is too long")
+	}
+	if project.Color != "" && !projectColor.MatchString(project.Color) {
+		return errors.New("Color must be a #RRGGBB value")
+	}
+	return nil
+}
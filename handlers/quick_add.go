@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/quickadd"
+	"github.com/Anwarjondev/todo-api-go/store"
+)
+
+const maxQuickTextLength = 1000
+
+// QuickAddTodo creates a todo from a line of text
+// @Summary Quick Add Todo
+// @Description Create a todo from one line of text such as "Pay rent tomorrow 9am #home !high every month". Dates, times, #tags, !priorities and recurrences are recognized and removed from the title; missing tags are created. Relative dates are read in the given timezone. The response lists what was recognized.
+// @Tags Todos
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param todo body models.QuickTodoModel true "Todo text"
+// @Success 201 {object} models.QuickTodo
+// @Header 201 {string} ETag "The todo's version"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/quick [post]
+func (h *TodoHandler) QuickAddTodo(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	var input models.QuickTodoModel
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if len(input.Text) > maxQuickTextLength {
+		http.Error(w, "Text is too long", http.StatusBadRequest)
+		return
+	}
+	loc, err := time.LoadLocation(input.Timezone)
+	if err != nil {
+		http.Error(w, "Invalid timezone", http.StatusBadRequest)
+		return
+	}
+	parsed, err := quickadd.Parse(input.Text, time.Now().In(loc))
+	if errors.Is(err, quickadd.ErrNoTitle) {
+		http.Error(w, "Title is required", http.StatusBadRequest)
+		return
+	} else if errors.Is(err, quickadd.ErrTooFar) {
+		http.Error(w, fmt.Sprintf("Dates and recurrences can reach at most %d years ahead", quickadd.MaxYears), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, name := range parsed.Tags {
+		if len(name) > maxTagNameLength {
+			http.Error(w, "Tag name is too long", http.StatusBadRequest)
+			return
+		}
+	}
+
+	projectID, ok := h.todoProject(w, r, input.ProjectID, userID)
+	if !ok {
+		return
+	}
+	created := models.Todo{
+		Title:     parsed.Title,
+		UserId:    userID,
+		ProjectID: projectID,
+		DueAt:     parsed.DueAt,
+		RRule:     parsed.RRule,
+		Priority:  parsed.Priority,
+		Tags:      []models.Tag{},
+	}
+	if created.RRule != "" {
+		created.Timezone = loc.String()
+	}
+	if !checkPriority(w, &created) || !h.applyStatus(w, r, models.Todo{}, &created) {
+		return
+	}
+	if !h.applyRecurrence(w, r, models.Todo{}, &created, false) {
+		return
+	}
+	if err := h.Todos.CreateTodo(r.Context(), &created); err != nil {
+		http.Error(w, "Error creating with todo", http.StatusInternalServerError)
+		return
+	}
+	if len(parsed.Tags) > 0 {
+		if err := h.tagTodo(r.Context(), created.ID, userID, parsed.Tags); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+	}
+	// Reloading fills in what the list and get endpoints show, such as the
+	// status name and blockers.
+	if created, err = h.Todos.GetTodo(r.Context(), created.ID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	quick := models.QuickTodo{Todo: created, Recognized: []models.QuickToken{}}
+	for _, tok := range parsed.Recognized {
+		quick.Recognized = append(quick.Recognized, models.QuickToken{Type: tok.Kind, Text: tok.Text, Value: tok.Value})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/todos/%d", created.ID))
+	setTodoETag(w, created)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(quick)
+}
+
+// tagTodo attaches the user's tags with the given names to a todo, creating
+// the ones that do not exist yet.
+func (h *TodoHandler) tagTodo(ctx context.Context, todoID, userID int, names []string) error {
+	tags, err := h.Tags.ListTags(ctx, userID)
+	if err != nil {
+		return err
+	}
+	ids := make(map[string]int)
+	for _, tag := range tags {
+		ids[tag.Name] = tag.ID
+	}
+	for _, name := range names {
+		id, ok := ids[name]
+		if !ok {
+			tag := models.Tag{UserID: userID, Name: name}
+			if err := h.Tags.CreateTag(ctx, &tag); err != nil && !errors.Is(err, store.ErrTagExists) {
+				return err
+			} else if err != nil {
+				// Created concurrently; look it up again.
+				return h.tagTodo(ctx, todoID, userID, names)
+			}
+			id = tag.ID
+			ids[name] = id
+		}
+		if err := h.Tags.AttachTag(ctx, todoID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/rank"
+	"github.com/Anwarjondev/todo-api-go/store"
+)
+
+// errUnranked is returned by moveBounds when a todo next to the move has no
+// rank yet.
+var errUnranked = errors.New("unranked todo")
+
+// MoveTodo changes where a todo sits in its owner's order
+// @Summary Move Todo
+// @Description Place a todo directly after the todo after, directly before the todo before, or between the two. Both must be todos of the same owner. Only the moved todo's rank changes. Users can move their own todos and those shared with them as editor.
+// @Tags Todos
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param move body models.MoveTodoModel true "Where to put the todo"
+// @Param If-Match header string false "ETag of the version the change is based on"
+// @Success 200 {object} models.Todo
+// @Header 200 {string} ETag "The todo's new version"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 412 {object} models.TodoConflict "The todo has changed since that version"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id}/move [post]
+func (h *TodoHandler) MoveTodo(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	var req models.MoveTodoModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.After == nil && req.Before == nil {
+		http.Error(w, "after or before is required", http.StatusBadRequest)
+		return
+	}
+	todo, ok := editableTodo(w, r, h.Todos, h.Shares, id, userID)
+	if !ok || !checkIfMatch(w, r, todo) {
+		return
+	}
+	key, ok := h.moveRank(w, r, todo, req)
+	if !ok {
+		return
+	}
+	err := h.Todos.MoveTodo(r.Context(), id, key, todo.Version)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Todo not found", http.StatusNotFound)
+		return
+	} else if errors.Is(err, store.ErrVersionConflict) {
+		writeStoredConflict(w, r, h.Todos, id)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if todo, err = h.Todos.GetTodo(r.Context(), id); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	setTodoETag(w, todo)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(todo)
+}
+
+// moveRank returns the rank that places todo as req asks. When the todos
+// around the new place share a rank or have none yet, the owner's ranks are
+// rebalanced first. It writes an error response and returns false on
+// failure.
+func (h *TodoHandler) moveRank(w http.ResponseWriter, r *http.Request, todo models.Todo, req models.MoveTodoModel) (string, bool) {
+	for rebalanced := false; ; rebalanced = true {
+		lo, hi, err := h.moveBounds(r, todo, req)
+		var key string
+		if err == nil {
+			key, err = rank.Between(lo, hi)
+		}
+		var bad badMove
+		switch {
+		case err == nil:
+			return key, true
+		case errors.As(err, &bad):
+			http.Error(w, bad.Error(), http.StatusBadRequest)
+			return "", false
+		case rebalanced || !(errors.Is(err, errUnranked) || errors.Is(err, rank.ErrNoRoom)):
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return "", false
+		}
+		if err := h.Ranks.RebalanceRanks(r.Context(), todo.UserId); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return "", false
+		}
+	}
+}
+
+// badMove is a moveBounds error caused by the request.
+type badMove string
+
+func (e badMove) Error() string { return string(e) }
+
+// moveBounds returns the ranks todo must be placed between: those of the
+// requested neighbours, or of the todo on the far side of a single one. An
+// empty bound means the start or end of the order.
+func (h *TodoHandler) moveBounds(r *http.Request, todo models.Todo, req models.MoveTodoModel) (lo, hi string, err error) {
+	neighbour := func(id int) (models.Todo, error) {
+		if id == todo.ID {
+			return models.Todo{}, badMove("A todo cannot be moved next to itself")
+		}
+		t, err := h.Todos.GetTodo(r.Context(), id)
+		if errors.Is(err, store.ErrNotFound) || (err == nil && t.UserId != todo.UserId) {
+			return models.Todo{}, badMove("after and before must be other todos of the same owner")
+		} else if err == nil && t.Rank == "" {
+			err = errUnranked
+		}
+		return t, err
+	}
+	// adjacent returns the rank of the todo next to t in the given direction,
+	// skipping the todo being moved.
+	adjacent := func(t models.Todo, desc bool) (string, error) {
+		filter := store.TodoFilter{UserID: todo.UserId, Sort: store.SortByRank, Desc: desc, After: &t, Limit: 2}
+		todos, err := h.Todos.ListTodos(r.Context(), filter)
+		if err != nil {
+			return "", err
+		}
+		for _, next := range todos {
+			if next.ID == todo.ID {
+				continue
+			}
+			if next.Rank == "" {
+				return "", errUnranked
+			}
+			return next.Rank, nil
+		}
+		return "", nil
+	}
+
+	var after, before models.Todo
+	if req.After != nil {
+		if after, err = neighbour(*req.After); err != nil {
+			return "", "", err
+		}
+	}
+	if req.Before != nil {
+		if before, err = neighbour(*req.Before); err != nil {
+			return "", "", err
+		}
+	}
+	switch {
+	case req.After != nil && req.Before != nil:
+		if after.Rank > before.Rank || (after.Rank == before.Rank && after.ID > before.ID) {
+			return "", "", badMove("after must come before before")
+		}
+		return after.Rank, before.Rank, nil
+	case req.After != nil:
+		hi, err = adjacent(after, false)
+		return after.Rank, hi, err
+	default:
+		lo, err = adjacent(before, true)
+		return lo, before.Rank, err
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/recurrence"
+	"github.com/Anwarjondev/todo-api-go/store"
+)
+
+// editScope reads the scope query parameter of a change to an occurrence of
+// a recurring todo: "this" (the default) changes only that occurrence,
+// "future" also changes every occurrence still to come. It writes a 400 and
+// returns ok false for any other value.
+func editScope(w http.ResponseWriter, r *http.Request) (future, ok bool) {
+	switch r.URL.Query().Get("scope") {
+	case "", "this":
+		return false, true
+	case "future":
+		return true, true
+	}
+	http.Error(w, "scope must be this or future", http.StatusBadRequest)
+	return false, false
+}
+
+// checkRecurrence validates the rrule and timezone of todo, filling in the
+// default time zone. It writes a 400 when they are invalid.
+func checkRecurrence(w http.ResponseWriter, todo *models.Todo) bool {
+	if todo.RRule == "" {
+		if todo.Timezone != "" {
+			http.Error(w, "timezone is only used together with rrule", http.StatusBadRequest)
+			return false
+		}
+		return true
+	}
+	if todo.Timezone == "" {
+		todo.Timezone = "UTC"
+	}
+	if todo.DueAt == nil {
+		http.Error(w, "A recurring todo needs a due_at", http.StatusBadRequest)
+		return false
+	}
+	if _, err := recurrence.Parse(todo.RRule, todo.Timezone, *todo.DueAt); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// startSeries makes todo the first occurrence of a new series built from its
+// title, project and rrule, with the rule starting at dtstart.
+func (h *TodoHandler) startSeries(ctx context.Context, todo *models.Todo, dtstart time.Time) error {
+	series := models.Series{
+		UserID:    todo.UserId,
+		Title:     todo.Title,
+		ProjectID: todo.ProjectID,
+		RRule:     todo.RRule,
+		Timezone:  todo.Timezone,
+		DTStart:   dtstart,
+	}
+	if err := h.Series.CreateSeries(ctx, &series); err != nil {
+		return err
+	}
+	todo.SeriesID = &series.ID
+	return nil
+}
+
+// applyRecurrence carries the recurrence side of an edit from existing to
+// todo. Setting an rrule on a one-off todo starts a series. For an
+// occurrence, a future-scoped edit splits the series: the occurrence starts
+// a new series with its new title, project and rule, which later
+// occurrences are created from, while earlier occurrences keep the old one.
+// Clearing the rrule ends the series at this occurrence. It writes an error
+// response and returns false when the edit is not allowed.
+func (h *TodoHandler) applyRecurrence(w http.ResponseWriter, r *http.Request, existing models.Todo, todo *models.Todo, future bool) bool {
+	if todo.RRule == "" && todo.Timezone == existing.Timezone {
+		// Clearing the rule clears the time zone that came with it.
+		todo.Timezone = ""
+	}
+	if !checkRecurrence(w, todo) {
+		return false
+	}
+	if todo.SeriesID == nil {
+		if todo.RRule != "" {
+			if err := h.startSeries(r.Context(), todo, *todo.DueAt); err != nil {
+				http.Error(w, "Database error", http.StatusInternalServerError)
+				return false
+			}
+		}
+		return true
+	}
+
+	ruleChanged := todo.RRule != existing.RRule || todo.Timezone != existing.Timezone
+	if !future {
+		if ruleChanged {
+			http.Error(w, "rrule and timezone can only be changed with scope=future", http.StatusBadRequest)
+			return false
+		}
+		return true
+	}
+	if todo.RRule == "" {
+		// The occurrence leaves its series and no further ones are created.
+		todo.SeriesID = nil
+		return true
+	}
+	series, err := h.Series.GetSeries(r.Context(), *todo.SeriesID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return false
+	}
+	dtstart := series.DTStart
+	if ruleChanged || !sameTime(todo.DueAt, existing.DueAt) {
+		// A new rule or date counts from this occurrence.
+		dtstart = *todo.DueAt
+	}
+	if err := h.startSeries(r.Context(), todo, dtstart); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// scheduleNext creates the occurrence that follows todo in its series, if
+// the rule has one. The next occurrence is the first one after todo's due
+// date, and its reminder keeps the same offset from the due date. Creating
+// an occurrence that already exists is not an error, so calling this twice
+// for the same todo creates only one successor.
+func (h *TodoHandler) scheduleNext(ctx context.Context, todo models.Todo) error {
+	if todo.SeriesID == nil || todo.DueAt == nil {
+		return nil
+	}
+	series, err := h.Series.GetSeries(ctx, *todo.SeriesID)
+	if err != nil {
+		return err
+	}
+	rule, err := recurrence.Parse(series.RRule, series.Timezone, series.DTStart)
+	if err != nil {
+		return err
+	}
+	due, ok := rule.Next(*todo.DueAt)
+	if !ok {
+		return nil
+	}
+	next := models.Todo{
+		Title:      series.Title,
+		UserId:     todo.UserId,
+		AssigneeID: todo.AssigneeID,
+		ProjectID:  series.ProjectID,
+		ParentID:   todo.ParentID,
+		SeriesID:   todo.SeriesID,
+		Priority:   todo.Priority,
+		DueAt:      &due,
+	}
+	if todo.RemindAt != nil {
+		remindAt := due.Add(todo.RemindAt.Sub(*todo.DueAt))
+		next.RemindAt = &remindAt
+	}
+	err = h.Todos.CreateTodo(ctx, &next)
+	if errors.Is(err, store.ErrOccurrenceExists) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/store"
+)
+
+// ShareHandler serves the endpoints for sharing todos with other users.
+type ShareHandler struct {
+	Shares store.ShareStore
+	Users  store.UserStore
+	Todos  store.TodoStore
+}
+
+func NewShareHandler(shares store.ShareStore, users store.UserStore, todos store.TodoStore) *ShareHandler {
+	return &ShareHandler{Shares: shares, Users: users, Todos: todos}
+}
+
+// GetShares lists the current user's shares
+// @Summary Get Shares
+// @Description List the shares the current user granted to others and those others granted to them
+// @Tags Shares
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.Share
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Server error"
+// @Router /shares [get]
+func (h *ShareHandler) GetShares(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	shares, err := h.Shares.ListShares(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if shares == nil {
+		shares = []models.Share{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shares)
+}
+
+// CreateShare shares todos with another user
+// @Summary Create Share
+// @Description Give another user access to one of the current user's todos, including its subtasks, or to all of them when todo_id is left out. Viewers can read the shared todos; editors can also change and delete them.
+// @Tags Shares
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param share body models.ShareModel true "Who to share with and what"
+// @Success 201 {object} models.Share
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 409 {string} string "Already shared with that user"
+// @Failure 500 {string} string "Server error"
+// @Router /shares [post]
+func (h *ShareHandler) CreateShare(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	var req models.ShareModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.Role != models.ShareViewer && req.Role != models.ShareEditor {
+		http.Error(w, "role must be viewer or editor", http.StatusBadRequest)
+		return
+	}
+	grantee, err := h.Users.GetUserByUsername(r.Context(), strings.TrimSpace(req.Username))
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "User not found", http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if grantee.ID == userID {
+		http.Error(w, "You cannot share with yourself", http.StatusBadRequest)
+		return
+	}
+	if req.TodoID != nil {
+		// Only the owner can share a todo; editors cannot pass it on.
+		if _, ok := ownTodo(w, r, h.Todos, *req.TodoID, userID); !ok {
+			return
+		}
+	}
+
+	share := models.Share{
+		OwnerID:   userID,
+		GranteeID: grantee.ID,
+		TodoID:    req.TodoID,
+		Role:      req.Role,
+		CreatedAt: time.Now(),
+	}
+	err = h.Shares.CreateShare(r.Context(), &share)
+	if errors.Is(err, store.ErrShareExists) {
+		http.Error(w, "Already shared with that user; revoke the share to change its role", http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/shares/"+strconv.Itoa(share.ID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(share)
+}
+
+// DeleteShare revokes a share
+// @Summary Delete Share
+// @Description Revoke a share. The owner can revoke it, and the grantee can give it up.
+// @Tags Shares
+// @Security BearerAuth
+// @Param id path int true "Share ID"
+// @Success 204 {string} string "Share revoked"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Share not found"
+// @Failure 500 {string} string "Server error"
+// @Router /shares/{id} [delete]
+func (h *ShareHandler) DeleteShare(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid share id", http.StatusBadRequest)
+		return
+	}
+	share, err := h.Shares.GetShare(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && share.OwnerID != userID && share.GranteeID != userID) {
+		http.Error(w, "Share not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	err = h.Shares.DeleteShare(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Share not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
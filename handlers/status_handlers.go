@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/store"
+)
+
+const maxStatusNameLength = 50
+
+// statusPatchFields lists the status fields a client may change with PATCH.
+var statusPatchFields = map[string]bool{
+	"name":        true,
+	"position":    true,
+	"is_done":     true,
+	"transitions": true,
+}
+
+// StatusHandler serves the endpoints for the current user's workflow
+// statuses and the board built from them.
+type StatusHandler struct {
+	Statuses store.StatusStore
+	Todos    store.TodoStore
+}
+
+func NewStatusHandler(statuses store.StatusStore, todos store.TodoStore) *StatusHandler {
+	return &StatusHandler{Statuses: statuses, Todos: todos}
+}
+
+// GetStatuses lists the current user's statuses
+// @Summary Get Statuses
+// @Description List the current user's workflow statuses ordered by position
+// @Tags Statuses
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.Status
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Server error"
+// @Router /statuses [get]
+func (h *StatusHandler) GetStatuses(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	statuses, err := h.Statuses.ListStatuses(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statuses)
+}
+
+// CreateStatus creates a status
+// @Summary Create Status
+// @Description Add a status to the current user's workflow. transitions lists the ids of the statuses a todo may move to from this one; leave it empty to allow any.
+// @Tags Statuses
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param status body models.StatusModel true "Status data"
+// @Success 201 {object} models.Status
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 409 {string} string "Status already exists"
+// @Failure 500 {string} string "Server error"
+// @Router /statuses [post]
+func (h *StatusHandler) CreateStatus(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	var req models.StatusModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	statuses, err := h.Statuses.ListStatuses(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	status := models.Status{
+		UserID:      userID,
+		Name:        strings.TrimSpace(req.Name),
+		Position:    req.Position,
+		IsDone:      req.IsDone,
+		Transitions: req.Transitions,
+	}
+	if err := validateStatus(status, statuses); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = h.Statuses.CreateStatus(r.Context(), &status)
+	if errors.Is(err, store.ErrStatusExists) {
+		http.Error(w, "Status already exists", http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if status.Transitions == nil {
+		status.Transitions = []int{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/statuses/"+strconv.Itoa(status.ID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(status)
+}
+
+// PatchStatus partially updates a status
+// @Summary Patch Status
+// @Description Change only the supplied fields of a status using a JSON Merge Patch (RFC 7396). Whether a status counts as done can only change while no todos are in it, and every user keeps at least one open and one done status.
+// @Tags Statuses
+// @Security BearerAuth
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path int true "Status ID"
+// @Param patch body models.PatchStatusModel true "Fields to change"
+// @Success 200 {object} models.Status
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Status not found"
+// @Failure 409 {string} string "Status already exists or is in use"
+// @Failure 415 {string} string "Unsupported media type"
+// @Failure 500 {string} string "Server error"
+// @Router /statuses/{id} [patch]
+func (h *StatusHandler) PatchStatus(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	patch, err := decodeMergePatch(r, statusPatchFields)
+	if errors.Is(err, errUnsupportedMediaType) {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	} else if err != nil {
+		http.Error(w, "Invalid request payload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	existing, ok := h.ownStatus(w, r, userID)
+	if !ok {
+		return
+	}
+
+	status := existing
+	if err := applyMergePatch(&status, patch); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	status.ID = existing.ID
+	status.UserID = existing.UserID
+	status.Name = strings.TrimSpace(status.Name)
+	statuses, err := h.Statuses.ListStatuses(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if err := validateStatus(status, statuses); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if status.IsDone != existing.IsDone && !keepsOpenAndDone(statuses, status.ID, &status) {
+		http.Error(w, "Every workflow needs at least one open and one done status", http.StatusConflict)
+		return
+	}
+	err = h.Statuses.UpdateStatus(r.Context(), &status)
+	if errors.Is(err, store.ErrStatusExists) {
+		http.Error(w, "Status already exists", http.StatusConflict)
+		return
+	} else if errors.Is(err, store.ErrStatusInUse) {
+		http.Error(w, "Move the todos out of this status before changing whether it counts as done", http.StatusConflict)
+		return
+	} else if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Status not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if status, err = h.Statuses.GetStatus(r.Context(), status.ID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// DeleteStatus deletes a status
+// @Summary Delete Status
+// @Description Delete one of the current user's statuses. Statuses that todos are in cannot be deleted, and every user keeps at least one open and one done status.
+// @Tags Statuses
+// @Security BearerAuth
+// @Param id path int true "Status ID"
+// @Success 204 {string} string "Status deleted"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Status not found"
+// @Failure 409 {string} string "Status is in use"
+// @Failure 500 {string} string "Server error"
+// @Router /statuses/{id} [delete]
+func (h *StatusHandler) DeleteStatus(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	status, ok := h.ownStatus(w, r, userID)
+	if !ok {
+		return
+	}
+	statuses, err := h.Statuses.ListStatuses(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if !keepsOpenAndDone(statuses, status.ID, nil) {
+		http.Error(w, "Every workflow needs at least one open and one done status", http.StatusConflict)
+		return
+	}
+	err = h.Statuses.DeleteStatus(r.Context(), status.ID)
+	if errors.Is(err, store.ErrStatusInUse) {
+		http.Error(w, "Move the todos out of this status before deleting it", http.StatusConflict)
+		return
+	} else if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Status not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetBoard groups the current user's todos by status
+// @Summary Get Board
+// @Description List the current user's statuses in order, each with the todos in it in rank order. Accepts the same filters as GET /todos.
+// @Tags Statuses
+// @Security BearerAuth
+// @Produce json
+// @Param project_id query int false "Only todos in this project"
+// @Param assignee query string false "Only todos assigned to this user id, or to the current user with me"
+// @Param due query string false "Filter by due date" Enums(overdue, today, upcoming)
+// @Param tz query string false "IANA time zone that defines today (default UTC)"
+// @Param tag query []string false "Only todos with these tag names" collectionFormat(multi)
+// @Param tag_match query string false "Whether todos need all the given tags or any of them (default all)" Enums(all, any)
+// @Success 200 {array} models.BoardColumn
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Server error"
+// @Router /board [get]
+func (h *StatusHandler) GetBoard(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	filter := store.TodoFilter{UserID: userID, Sort: store.SortByRank}
+	if !parseTodoFilter(w, r, &filter) {
+		return
+	}
+	statuses, err := h.Statuses.ListStatuses(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	todos, err := h.Todos.ListTodos(r.Context(), filter)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	board := make([]models.BoardColumn, len(statuses))
+	column := make(map[int]*models.BoardColumn, len(statuses))
+	for i, status := range statuses {
+		board[i] = models.BoardColumn{Status: status, Todos: []models.Todo{}}
+		column[status.ID] = &board[i]
+	}
+	for _, todo := range todos {
+		if c, ok := column[todo.StatusID]; ok {
+			c.Todos = append(c.Todos, todo)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(board)
+}
+
+// ownStatus loads the status named by the {id} path value, writing a 404
+// unless it belongs to userID.
+func (h *StatusHandler) ownStatus(w http.ResponseWriter, r *http.Request, userID int) (models.Status, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid status id", http.StatusBadRequest)
+		return models.Status{}, false
+	}
+	status, err := h.Statuses.GetStatus(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && status.UserID != userID) {
+		http.Error(w, "Status not found", http.StatusNotFound)
+		return models.Status{}, false
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return models.Status{}, false
+	}
+	return status, true
+}
+
+// validateStatus checks the client-controlled fields of a status against
+// the user's other statuses.
+func validateStatus(status models.Status, statuses []models.Status) error {
+	if status.Name == "" {
+		return errors.New("Name is required")
+	}
+	if len(status.Name) > maxStatusNameLength {
+		return errors.New("Name is too long")
+	}
+	for _, id := range status.Transitions {
+		found := id == status.ID
+		for _, s := range statuses {
+			found = found || s.ID == id
+		}
+		if !found {
+			return fmt.Errorf("Transition to unknown status %d", id)
+		}
+	}
+	return nil
+}
+
+// keepsOpenAndDone reports whether statuses still include an open and a done
+// status once the status id is replaced by changed, or removed when changed
+// is nil.
+func keepsOpenAndDone(statuses []models.Status, id int, changed *models.Status) bool {
+	var open, done bool
+	for _, s := range statuses {
+		if s.ID == id {
+			if changed == nil {
+				continue
+			}
+			s = *changed
+		}
+		open = open || !s.IsDone
+		done = done || s.IsDone
+	}
+	return open && done
+}
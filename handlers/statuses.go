@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+// applyStatus keeps the status and completed flag of an edited todo in step.
+// A new status sets completed from whether it counts as done; a changed
+// completed flag alone moves the todo to the first status on that side. The
+// move must be one the todo's current status allows. It writes an error
+// response and returns false when the status is unknown or the move is not
+// allowed.
+func (h *TodoHandler) applyStatus(w http.ResponseWriter, r *http.Request, existing models.Todo, todo *models.Todo) bool {
+	if todo.StatusID == existing.StatusID && todo.Completed == existing.Completed {
+		return true
+	}
+	statuses, err := h.Statuses.ListStatuses(r.Context(), todo.UserId)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return false
+	}
+	byID := make(map[int]models.Status, len(statuses))
+	for _, status := range statuses {
+		byID[status.ID] = status
+	}
+	if todo.StatusID != existing.StatusID {
+		status, ok := byID[todo.StatusID]
+		if !ok {
+			http.Error(w, "Status not found", http.StatusBadRequest)
+			return false
+		}
+		todo.Completed = status.IsDone
+	} else {
+		todo.StatusID = 0
+		for _, status := range statuses {
+			if status.IsDone == todo.Completed {
+				todo.StatusID = status.ID
+				break
+			}
+		}
+		if todo.StatusID == 0 {
+			http.Error(w, "No status to move the todo to", http.StatusConflict)
+			return false
+		}
+	}
+	from, ok := byID[existing.StatusID]
+	if ok && !from.Allows(todo.StatusID) {
+		http.Error(w, fmt.Sprintf("Cannot move a todo from %s to %s", from.Name, byID[todo.StatusID].Name), http.StatusConflict)
+		return false
+	}
+	return true
+}
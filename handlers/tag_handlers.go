@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/store"
+)
+
+const maxTagNameLength = 50
+
+// TagHandler serves the tag endpoints.
+type TagHandler struct {
+	Tags  store.TagStore
+	Todos store.TodoStore
+}
+
+func NewTagHandler(tags store.TagStore, todos store.TodoStore) *TagHandler {
+	return &TagHandler{Tags: tags, Todos: todos}
+}
+
+// GetTags lists the current user's tags
+// @Summary Get Tags
+// @Description List the current user's tags ordered by name
+// @Tags Tags
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.Tag
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Server error"
+// @Router /tags [get]
+func (h *TagHandler) GetTags(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	tags, err := h.Tags.ListTags(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+// CreateTag creates a tag
+// @Summary Create Tag
+// @Description Create a tag for the current user. Names are unique per user.
+// @Tags Tags
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param tag body models.TagModel true "Tag data"
+// @Success 201 {object} models.Tag
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 409 {string} string "Tag already exists"
+// @Failure 500 {string} string "Server error"
+// @Router /tags [post]
+func (h *TagHandler) CreateTag(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	name, ok := decodeTagName(w, r)
+	if !ok {
+		return
+	}
+	tag := models.Tag{UserID: userID, Name: name}
+	err := h.Tags.CreateTag(r.Context(), &tag)
+	if errors.Is(err, store.ErrTagExists) {
+		http.Error(w, "Tag already exists", http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tag)
+}
+
+// RenameTag renames a tag
+// @Summary Rename Tag
+// @Description Rename one of the current user's tags
+// @Tags Tags
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Param tag body models.TagModel true "New name"
+// @Success 200 {object} models.Tag
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Tag not found"
+// @Failure 409 {string} string "Tag already exists"
+// @Failure 500 {string} string "Server error"
+// @Router /tags/{id} [put]
+func (h *TagHandler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	tag, ok := h.ownTag(w, r, r.PathValue("id"), userID)
+	if !ok {
+		return
+	}
+	name, ok := decodeTagName(w, r)
+	if !ok {
+		return
+	}
+	err := h.Tags.RenameTag(r.Context(), tag.ID, name)
+	if errors.Is(err, store.ErrTagExists) {
+		http.Error(w, "Tag already exists", http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	tag.Name = name
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag)
+}
+
+// DeleteTag deletes a tag
+// @Summary Delete Tag
+// @Description Delete one of the current user's tags and remove it from every todo
+// @Tags Tags
+// @Security BearerAuth
+// @Param id path int true "Tag ID"
+// @Success 204 {string} string "Tag deleted"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Tag not found"
+// @Failure 500 {string} string "Server error"
+// @Router /tags/{id} [delete]
+func (h *TagHandler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	tag, ok := h.ownTag(w, r, r.PathValue("id"), userID)
+	if !ok {
+		return
+	}
+	if err := h.Tags.DeleteTag(r.Context(), tag.ID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AttachTag adds a tag to a todo
+// @Summary Attach Tag
+// @Description Add one of the current user's tags to one of their todos
+// @Tags Tags
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param tagID path int true "Tag ID"
+// @Success 200 {object} models.Todo
+// @Header 200 {string} ETag "The todo's version"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Tag not found"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id}/tags/{tagID} [put]
+func (h *TagHandler) AttachTag(w http.ResponseWriter, r *http.Request) {
+	h.changeTodoTag(w, r, h.Tags.AttachTag)
+}
+
+// DetachTag removes a tag from a todo
+// @Summary Detach Tag
+// @Description Remove a tag from one of the current user's todos
+// @Tags Tags
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param tagID path int true "Tag ID"
+// @Success 200 {object} models.Todo
+// @Header 200 {string} ETag "The todo's version"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Tag not found"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id}/tags/{tagID} [delete]
+func (h *TagHandler) DetachTag(w http.ResponseWriter, r *http.Request) {
+	h.changeTodoTag(w, r, h.Tags.DetachTag)
+}
+
+// changeTodoTag checks that both the todo and the tag in the path belong to
+// the current user, applies change and responds with the updated todo.
+func (h *TagHandler) changeTodoTag(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, todoID, tagID int) error) {
+	userID := r.Context().Value("user_id").(int)
+
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := ownTodo(w, r, h.Todos, id, userID); !ok {
+		return
+	}
+	tag, ok := h.ownTag(w, r, r.PathValue("tagID"), userID)
+	if !ok {
+		return
+	}
+	err := change(r.Context(), id, tag.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Tag is not attached to this todo", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	todo, err := h.Todos.GetTodo(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	setTodoETag(w, todo)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(todo)
+}
+
+// ownTag loads the tag with the given id and checks that it belongs to
+// userID, writing a 404 when it does not exist or belongs to someone else.
+func (h *TagHandler) ownTag(w http.ResponseWriter, r *http.Request, rawID string, userID int) (models.Tag, bool) {
+	id, err := strconv.Atoi(rawID)
+	if err != nil {
+		http.Error(w, "Invalid tag id", http.StatusBadRequest)
+		return models.Tag{}, false
+	}
+	tag, err := h.Tags.GetTag(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && tag.UserID != userID) {
+		http.Error(w, "Tag not found", http.StatusNotFound)
+		return models.Tag{}, false
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return models.Tag{}, false
+	}
+	return tag, true
+}
+
+// decodeTagName reads a models.TagModel and returns its trimmed, validated name.
+func decodeTagName(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req models.TagModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return "", false
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return "", false
+	}
+	if len(name) > maxTagNameLength {
+		http.Error(w, "Name is too long", http.StatusBadRequest)
+		return "", false
+	}
+	return name, true
+}
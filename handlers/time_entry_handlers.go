@@ -0,0 +1,530 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/store"
+)
+
+const maxTimeEntryNoteLength = 1000
+
+var timeEntryPatchFields = map[string]bool{
+	"started_at": true,
+	"stopped_at": true,
+	"note":       true,
+}
+
+// TimeEntryHandler serves the time tracking endpoints. Users track time on
+// todos they can change, each user has at most one running timer, and
+// entries can only be edited by whoever tracked them.
+type TimeEntryHandler struct {
+	Entries store.TimeEntryStore
+	Todos   store.TodoStore
+	Shares  store.ShareStore
+}
+
+func NewTimeEntryHandler(entries store.TimeEntryStore, todos store.TodoStore, shares store.ShareStore) *TimeEntryHandler {
+	return &TimeEntryHandler{Entries: entries, Todos: todos, Shares: shares}
+}
+
+// StartTimer starts tracking time on a todo
+// @Summary Start Timer
+// @Description Start the current user's timer on a todo they own or can edit. Each user has at most one running timer; stop it before starting another.
+// @Tags Time Tracking
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param timer body models.TimerModel false "Optional note for the entry"
+// @Success 201 {object} models.TimeEntry
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 409 {string} string "A timer is already running"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id}/timer/start [post]
+func (h *TimeEntryHandler) StartTimer(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	var timer models.TimerModel
+	if err := json.NewDecoder(r.Body).Decode(&timer); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if len(timer.Note) > maxTimeEntryNoteLength {
+		http.Error(w, "Note is too long", http.StatusBadRequest)
+		return
+	}
+	if _, ok := editableTodo(w, r, h.Todos, h.Shares, id, userID); !ok {
+		return
+	}
+	entry := models.TimeEntry{TodoID: id, UserID: userID, StartedAt: time.Now(), Note: timer.Note}
+	h.createEntry(w, r, entry)
+}
+
+// StopTimer stops the running timer on a todo
+// @Summary Stop Timer
+// @Description Stop the current user's running timer on a todo
+// @Tags Time Tracking
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Success 200 {object} models.TimeEntry
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 409 {string} string "No timer is running on this todo"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id}/timer/stop [post]
+func (h *TimeEntryHandler) StopTimer(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	entry, err := h.Entries.RunningTimeEntry(r.Context(), userID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && entry.TodoID != id) {
+		http.Error(w, "No timer is running on this todo", http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	stoppedAt := time.Now()
+	if stoppedAt.Before(entry.StartedAt) {
+		stoppedAt = entry.StartedAt
+	}
+	entry.StoppedAt = &stoppedAt
+	h.updateEntry(w, r, entry)
+}
+
+// GetTimer returns the running timer
+// @Summary Get Timer
+// @Description Get the current user's running timer
+// @Tags Time Tracking
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.TimeEntry
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "No timer is running"
+// @Failure 500 {string} string "Server error"
+// @Router /timer [get]
+func (h *TimeEntryHandler) GetTimer(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	entry, err := h.Entries.RunningTimeEntry(r.Context(), userID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "No timer is running", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	setDuration(&entry, time.Now())
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
+
+// GetTimeEntries lists the time tracked on a todo
+// @Summary Get Time Entries
+// @Description List the time entries of every user on a todo, oldest first (users can see their own todos and those shared with them, admins can see any)
+// @Tags Time Tracking
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Success 200 {array} models.TimeEntry
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id}/time-entries [get]
+func (h *TimeEntryHandler) GetTimeEntries(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	role := r.Context().Value("role").(string)
+
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := readableTodo(w, r, h.Todos, h.Shares, id, userID, role); !ok {
+		return
+	}
+	entries, err := h.Entries.ListTimeEntries(r.Context(), store.TimeEntryFilter{TodoID: id})
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []models.TimeEntry{}
+	}
+	now := time.Now()
+	for i := range entries {
+		setDuration(&entries[i], now)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// CreateTimeEntry records time tracked without a timer
+// @Summary Create Time Entry
+// @Description Record time the current user spent on a todo they own or can edit. stopped_at is required and must be after started_at; time that is still running is tracked with the timer.
+// @Tags Time Tracking
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param entry body models.TimeEntryModel true "Time entry"
+// @Success 201 {object} models.TimeEntry
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id}/time-entries [post]
+func (h *TimeEntryHandler) CreateTimeEntry(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	var input models.TimeEntryModel
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if input.StoppedAt.IsZero() {
+		http.Error(w, "stopped_at is required, as an entry without it is a timer that is still running; start a timer instead", http.StatusBadRequest)
+		return
+	}
+	entry := models.TimeEntry{TodoID: id, UserID: userID, StartedAt: input.StartedAt, StoppedAt: &input.StoppedAt, Note: input.Note}
+	if err := validateTimeEntry(entry); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, ok := editableTodo(w, r, h.Todos, h.Shares, id, userID); !ok {
+		return
+	}
+	h.createEntry(w, r, entry)
+}
+
+// PatchTimeEntry partially updates a time entry
+// @Summary Patch Time Entry
+// @Description Change only the supplied fields of one of the current user's time entries using a JSON Merge Patch (RFC 7396). A stopped entry cannot be turned back into a running timer.
+// @Tags Time Tracking
+// @Security BearerAuth
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path int true "Time entry ID"
+// @Param patch body models.PatchTimeEntryModel true "Fields to change"
+// @Success 200 {object} models.TimeEntry
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Time entry not found"
+// @Failure 415 {string} string "Unsupported media type"
+// @Failure 500 {string} string "Server error"
+// @Router /time-entries/{id} [patch]
+func (h *TimeEntryHandler) PatchTimeEntry(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	patch, err := decodeMergePatch(r, timeEntryPatchFields)
+	if errors.Is(err, errUnsupportedMediaType) {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	} else if err != nil {
+		http.Error(w, "Invalid request payload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	existing, ok := h.ownEntry(w, r, userID)
+	if !ok {
+		return
+	}
+
+	entry := existing
+	if err := applyMergePatch(&entry, patch); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	entry.ID, entry.TodoID, entry.UserID = existing.ID, existing.TodoID, existing.UserID
+	if existing.StoppedAt != nil && entry.StoppedAt == nil {
+		http.Error(w, "A stopped time entry cannot be restarted", http.StatusBadRequest)
+		return
+	}
+	if err := validateTimeEntry(entry); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.updateEntry(w, r, entry)
+}
+
+// DeleteTimeEntry deletes a time entry
+// @Summary Delete Time Entry
+// @Description Delete one of the current user's time entries, including a running timer
+// @Tags Time Tracking
+// @Security BearerAuth
+// @Param id path int true "Time entry ID"
+// @Success 204 {string} string "Time entry deleted"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Time entry not found"
+// @Failure 500 {string} string "Server error"
+// @Router /time-entries/{id} [delete]
+func (h *TimeEntryHandler) DeleteTimeEntry(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	entry, ok := h.ownEntry(w, r, userID)
+	if !ok {
+		return
+	}
+	err := h.Entries.DeleteTimeEntry(r.Context(), entry.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Time entry not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTimeReport totals tracked time over a date range
+// @Summary Get Time Report
+// @Description Total the time entries started between two dates, per todo and per user. Without todo_id the report covers the current user's entries on all todos; with todo_id it covers every user's entries on that todo. Running timers count up to now. format=csv returns the entries as a CSV file.
+// @Tags Time Tracking
+// @Security BearerAuth
+// @Produce json
+// @Produce text/csv
+// @Param from query string false "First day, YYYY-MM-DD (default the first day of this month)"
+// @Param to query string false "Last day, YYYY-MM-DD, inclusive (default today)"
+// @Param tz query string false "IANA time zone the days are in (default UTC)"
+// @Param todo_id query int false "Report on one todo"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} models.TimeReport
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 500 {string} string "Server error"
+// @Router /time-report [get]
+func (h *TimeEntryHandler) GetTimeReport(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	role := r.Context().Value("role").(string)
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		http.Error(w, "format must be json or csv", http.StatusBadRequest)
+		return
+	}
+	loc, err := time.LoadLocation(query.Get("tz"))
+	if err != nil {
+		http.Error(w, "Invalid tz", http.StatusBadRequest)
+		return
+	}
+	now := time.Now()
+	today := now.In(loc)
+	from := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, loc)
+	to := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, loc)
+	for name, day := range map[string]*time.Time{"from": &from, "to": &to} {
+		if raw := query.Get(name); raw != "" {
+			if *day, err = time.ParseInLocation("2006-01-02", raw, loc); err != nil {
+				http.Error(w, name+" must be a date in the form YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	// to names the last day of the report.
+	to = to.AddDate(0, 0, 1)
+	if !from.Before(to) {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return
+	}
+
+	filter := store.TimeEntryFilter{UserID: userID, From: &from, To: &to}
+	if raw := query.Get("todo_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, "Invalid todo_id", http.StatusBadRequest)
+			return
+		}
+		if _, ok := readableTodo(w, r, h.Todos, h.Shares, id, userID, role); !ok {
+			return
+		}
+		filter.UserID, filter.TodoID = 0, id
+	}
+	entries, err := h.Entries.ListTimeEntries(r.Context(), filter)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	report := timeReport(entries, from, to, now)
+
+	if format == "csv" {
+		writeTimeReportCSV(w, report)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *TimeEntryHandler) createEntry(w http.ResponseWriter, r *http.Request, entry models.TimeEntry) {
+	err := h.Entries.CreateTimeEntry(r.Context(), &entry)
+	if errors.Is(err, store.ErrTimerRunning) {
+		http.Error(w, "A timer is already running; stop it first", http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	setDuration(&entry, time.Now())
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/time-entries/%d", entry.ID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+func (h *TimeEntryHandler) updateEntry(w http.ResponseWriter, r *http.Request, entry models.TimeEntry) {
+	err := h.Entries.UpdateTimeEntry(r.Context(), &entry)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Time entry not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if entry, err = h.Entries.GetTimeEntry(r.Context(), entry.ID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	setDuration(&entry, time.Now())
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
+
+// ownEntry loads the time entry named in the path, answering 404 for
+// entries tracked by other users.
+func (h *TimeEntryHandler) ownEntry(w http.ResponseWriter, r *http.Request, userID int) (models.TimeEntry, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid time entry id", http.StatusBadRequest)
+		return models.TimeEntry{}, false
+	}
+	entry, err := h.Entries.GetTimeEntry(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && entry.UserID != userID) {
+		http.Error(w, "Time entry not found", http.StatusNotFound)
+		return models.TimeEntry{}, false
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return models.TimeEntry{}, false
+	}
+	return entry, true
+}
+
+func validateTimeEntry(entry models.TimeEntry) error {
+	if entry.StartedAt.IsZero() {
+		return errors.New("started_at is required")
+	}
+	if entry.StoppedAt != nil && !entry.StoppedAt.After(entry.StartedAt) {
+		return errors.New("stopped_at must be after started_at")
+	}
+	if len(entry.Note) > maxTimeEntryNoteLength {
+		return errors.New("Note is too long")
+	}
+	return nil
+}
+
+// setDuration fills in the length of entry, counting a running timer up to
+// now.
+func setDuration(entry *models.TimeEntry, now time.Time) {
+	end := now
+	if entry.StoppedAt != nil {
+		end = *entry.StoppedAt
+	}
+	entry.DurationSeconds = max(int64(end.Sub(entry.StartedAt)/time.Second), 0)
+}
+
+// timeReport totals entries per todo and per user. Todos are listed by
+// title and users by id.
+func timeReport(entries []models.TimeEntry, from, to, now time.Time) models.TimeReport {
+	report := models.TimeReport{From: from, To: to, Todos: []models.TodoTime{}, Users: []models.UserTime{}, Entries: entries}
+	if report.Entries == nil {
+		report.Entries = []models.TimeEntry{}
+	}
+	todos := make(map[int]*models.TodoTime)
+	users := make(map[int]*models.UserTime)
+	for i := range report.Entries {
+		entry := &report.Entries[i]
+		setDuration(entry, now)
+		report.TotalSeconds += entry.DurationSeconds
+		if todos[entry.TodoID] == nil {
+			todos[entry.TodoID] = &models.TodoTime{TodoID: entry.TodoID, TodoTitle: entry.TodoTitle}
+		}
+		todos[entry.TodoID].TotalSeconds += entry.DurationSeconds
+		if users[entry.UserID] == nil {
+			users[entry.UserID] = &models.UserTime{UserID: entry.UserID}
+		}
+		users[entry.UserID].TotalSeconds += entry.DurationSeconds
+	}
+	for _, t := range todos {
+		report.Todos = append(report.Todos, *t)
+	}
+	sort.Slice(report.Todos, func(i, j int) bool {
+		if report.Todos[i].TodoTitle != report.Todos[j].TodoTitle {
+			return report.Todos[i].TodoTitle < report.Todos[j].TodoTitle
+		}
+		return report.Todos[i].TodoID < report.Todos[j].TodoID
+	})
+	for _, u := range users {
+		report.Users = append(report.Users, *u)
+	}
+	sort.Slice(report.Users, func(i, j int) bool { return report.Users[i].UserID < report.Users[j].UserID })
+	return report
+}
+
+// writeTimeReportCSV writes the entries of report as a CSV attachment, one
+// row per entry.
+func writeTimeReportCSV(w http.ResponseWriter, report models.TimeReport) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="time-report.csv"`)
+	out := csv.NewWriter(w)
+	out.Write([]string{"entry_id", "todo_id", "todo_title", "user_id", "started_at", "stopped_at", "duration_seconds", "note"})
+	for _, e := range report.Entries {
+		stoppedAt := ""
+		if e.StoppedAt != nil {
+			stoppedAt = e.StoppedAt.Format(time.RFC3339)
+		}
+		out.Write([]string{
+			strconv.Itoa(e.ID),
+			strconv.Itoa(e.TodoID),
+			csvText(e.TodoTitle),
+			strconv.Itoa(e.UserID),
+			e.StartedAt.Format(time.RFC3339),
+			stoppedAt,
+			strconv.FormatInt(e.DurationSeconds, 10),
+			csvText(e.Note),
+		})
+	}
+	out.Flush()
+}
+
+// csvText returns text written by a user for a CSV cell. Spreadsheets run a
+// cell starting with =, +, -, @, a tab or a carriage return as a formula, so
+// such text is prefixed with a quote, which they show as plain text.
+func csvText(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
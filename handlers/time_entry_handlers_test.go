@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+func TestWriteTimeReportCSVEscapesFormulas(t *testing.T) {
+	texts := map[string]string{
+		`=HYPERLINK("http://example.com","x")`: `'=HYPERLINK("http://example.com","x")`,
+		"+1":                                   "'+1",
+		"-1":                                   "'-1",
+		"@SUM(A1)":                             "'@SUM(A1)",
+		"\tindented":                           "'\tindented",
+		"\rreturn":                             "'\rreturn",
+		"Plain = ok":                           "Plain = ok",
+		"":                                     "",
+	}
+	start := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	var report models.TimeReport
+	for text := range texts {
+		report.Entries = append(report.Entries, models.TimeEntry{TodoTitle: text, Note: text, StartedAt: start})
+	}
+
+	rec := httptest.NewRecorder()
+	writeTimeReportCSV(rec, report)
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(report.Entries)+1 {
+		t.Fatalf("got %d rows, want %d", len(rows), len(report.Entries)+1)
+	}
+	for i, e := range report.Entries {
+		want := texts[e.TodoTitle]
+		got := []string{rows[i+1][2], rows[i+1][7]}
+		if !reflect.DeepEqual(got, []string{want, want}) {
+			t.Errorf("title and note %q written as %q, want %q", e.TodoTitle, got, want)
+		}
+	}
+}
+
+func TestCreateTimeEntryRequiresStoppedAt(t *testing.T) {
+	h := &TimeEntryHandler{}
+	r := httptest.NewRequest(http.MethodPost, "/todos/1/time-entries", strings.NewReader(`{"started_at": "2026-03-02T09:00:00Z"}`))
+	r.SetPathValue("id", "1")
+	r = r.WithContext(context.WithValue(r.Context(), "user_id", 1))
+	rec := httptest.NewRecorder()
+	h.CreateTimeEntry(rec, r)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "still running") {
+		t.Errorf("got %d %q, want 400 saying the timer is still running", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/store"
+)
+
+const (
+	defaultTodayLimit = 20
+	// todayDays is how many days from the start of today a todo may be due
+	// in to be listed, matching the last due date that scores points.
+	todayDays = 8
+)
+
+// Weights of the today score; see todayScore.
+const (
+	priorityPoints    = 10
+	overduePoints     = 50
+	lateDayPoints     = 2
+	maxLatePoints     = 20
+	dueTodayPoints    = 40
+	dueTomorrowPoints = 20
+	dueWeekPoints     = 10
+	blockingPoints    = 5
+	maxBlockingPoints = 15
+	blockedPoints     = -50
+)
+
+// GetToday lists the open todos to focus on
+// @Summary Get Today
+// @Description List the current user's open todos and those shared with them that are overdue or due within a week, highest score first. At most 200 are considered, earliest due first. The score adds up the priority (10 points per level above none), the due date (50 for overdue plus 2 per day late up to 20 more, 40 for due later today, 20 for tomorrow, 10 within a week), 5 per listed todo it blocks (up to 15) and -50 while the todo is blocked. Ties go to the earlier due date, then the user's own order. Accepts the filters of GET /todos.
+// @Tags Todos
+// @Security BearerAuth
+// @Produce json
+// @Param tz query string false "IANA time zone that defines today (default UTC)"
+// @Param limit query int false "Number of todos (1-200, default 20)"
+// @Param project_id query int false "Only todos in this project"
+// @Param assignee query string false "Only todos assigned to this user id, or to the current user with me"
+// @Param tag query []string false "Only todos with these tag names (repeat the parameter for several)" collectionFormat(multi)
+// @Success 200 {array} models.TodayTodo
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/today [get]
+func (h *TodoHandler) GetToday(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	query := r.URL.Query()
+
+	filter := store.TodoFilter{VisibleTo: userID}
+	if !parseTodoFilter(w, r, &filter) {
+		return
+	}
+	loc, err := time.LoadLocation(query.Get("tz"))
+	if err != nil {
+		http.Error(w, "Invalid tz", http.StatusBadRequest)
+		return
+	}
+	now := time.Now().In(loc)
+	// Todos due later than a week score nothing for their due date, so only
+	// overdue ones and those due within the week are loaded, earliest due
+	// first and no more than a page of the list endpoint.
+	open := false
+	filter.Completed = &open
+	end := time.Date(now.Year(), now.Month(), now.Day()+todayDays, 0, 0, 0, 0, loc)
+	if filter.DueBefore == nil || end.Before(*filter.DueBefore) {
+		filter.DueBefore = &end
+	}
+	filter.Sort = store.SortByDue
+	filter.Limit = maxPageSize
+	limit := defaultTodayLimit
+	if raw := query.Get("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 1 || limit > maxPageSize {
+			http.Error(w, "limit must be between 1 and "+strconv.Itoa(maxPageSize), http.StatusBadRequest)
+			return
+		}
+	}
+
+	todos, err := h.Todos.ListTodos(r.Context(), filter)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	blocks := make(map[int]int)
+	for _, todo := range todos {
+		for _, blockerID := range todo.BlockedBy {
+			blocks[blockerID]++
+		}
+	}
+	today := make([]models.TodayTodo, 0, len(todos))
+	for _, todo := range todos {
+		score, reasons := todayScore(todo, blocks[todo.ID], now)
+		today = append(today, models.TodayTodo{Todo: todo, Score: score, Reasons: reasons})
+	}
+	// Every listed todo has a due date. todos come in due date order, so
+	// the user's own order breaks the remaining ties.
+	sort.SliceStable(today, func(i, j int) bool {
+		a, b := today[i], today[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if !a.DueAt.Equal(*b.DueAt) {
+			return a.DueAt.Before(*b.DueAt)
+		}
+		return a.Rank < b.Rank
+	})
+	if len(today) > limit {
+		today = today[:limit]
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(today)
+}
+
+// todayScore scores an open todo for the today view, where higher scores
+// come first, and explains the score. blocks is the number of open todos
+// waiting for this one, and now carries the time zone that defines today.
+//
+// The score is the sum of:
+//
+//   - priority: 10 points per level above none, so 40 for urgent;
+//   - due date: 50 when overdue plus 2 per full day late, at most 20 more;
+//     otherwise 40 when due later today, 20 when due tomorrow and 10 when due
+//     within the next 7 days;
+//   - dependencies: 5 per open todo it blocks, at most 15, and -50 while the
+//     todo is blocked itself, as nothing can be done about it yet.
+func todayScore(todo models.Todo, blocks int, now time.Time) (int, []string) {
+	score := 0
+	var reasons []string
+	if level := slices.Index(models.Priorities, todo.Priority); level > 0 {
+		score += level * priorityPoints
+		reasons = append(reasons, todo.Priority+" priority")
+	}
+
+	if todo.DueAt != nil {
+		startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		switch due := *todo.DueAt; {
+		case due.Before(now):
+			late := int(now.Sub(due) / (24 * time.Hour))
+			score += overduePoints + min(late*lateDayPoints, maxLatePoints)
+			if late > 0 {
+				reasons = append(reasons, fmt.Sprintf("overdue by %s", plural(late, "day")))
+			} else {
+				reasons = append(reasons, "overdue")
+			}
+		case due.Before(startOfDay.AddDate(0, 0, 1)):
+			score += dueTodayPoints
+			reasons = append(reasons, "due today")
+		case due.Before(startOfDay.AddDate(0, 0, 2)):
+			score += dueTomorrowPoints
+			reasons = append(reasons, "due tomorrow")
+		case due.Before(startOfDay.AddDate(0, 0, 8)):
+			score += dueWeekPoints
+			reasons = append(reasons, "due this week")
+		}
+	}
+
+	if blocks > 0 {
+		score += min(blocks*blockingPoints, maxBlockingPoints)
+		reasons = append(reasons, "blocks "+plural(blocks, "todo"))
+	}
+	if todo.Blocked {
+		score += blockedPoints
+		reasons = append(reasons, "blocked")
+	}
+	if reasons == nil {
+		reasons = []string{}
+	}
+	return score, reasons
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return strconv.Itoa(n) + " " + noun + "s"
+}
+
+// checkPriority defaults an empty priority to none and rejects unknown
+// ones, writing a 400 and returning false.
+func checkPriority(w http.ResponseWriter, todo *models.Todo) bool {
+	if todo.Priority == "" {
+		todo.Priority = models.PriorityNone
+	}
+	if !slices.Contains(models.Priorities, todo.Priority) {
+		http.Error(w, "priority must be "+strings.Join(models.Priorities[:len(models.Priorities)-1], ", ")+" or "+models.PriorityUrgent, http.StatusBadRequest)
+		return false
+	}
+	return true
+}
@@ -1,26 +1,64 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
-	"github.com/Anwarjondev/todo-api-go/db"
+	"github.com/Anwarjondev/todo-api-go/blobs"
 	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/reminders"
+	"github.com/Anwarjondev/todo-api-go/store"
 )
 
-// GetTodos retrieves all todos or filters by status (completed/pending)
+// TodoHandler serves the todo endpoints.
+type TodoHandler struct {
+	Todos       store.TodoStore
+	Projects    store.ProjectStore
+	Series      store.SeriesStore
+	Attachments store.AttachmentStore
+	Blobs       blobs.BlobStore
+	Shares      store.ShareStore
+	Statuses    store.StatusStore
+	Ranks       store.RankStore
+	Tags        store.TagStore
+	History     store.HistoryStore
+	// Assignments, when set, is told about todos assigned to someone other
+	// than the user making the change.
+	Assignments reminders.AssignmentNotifier
+}
+
+func NewTodoHandler(todos store.TodoStore, projects store.ProjectStore, series store.SeriesStore, attachments store.AttachmentStore, blobs blobs.BlobStore, shares store.ShareStore, statuses store.StatusStore, ranks store.RankStore, tags store.TagStore, history store.HistoryStore) *TodoHandler {
+	return &TodoHandler{Todos: todos, Projects: projects, Series: series, Attachments: attachments, Blobs: blobs, Shares: shares, Statuses: statuses, Ranks: ranks, Tags: tags, History: history}
+}
+
+// GetTodos retrieves a page of todos, optionally filtered and sorted
 // @Summary Get Todos
-// @Description Retrieve todos based on user role
+// @Description Retrieve todos based on user role, one page at a time: users get their own todos and those shared with them, admins every todo. Filters can be combined; pass next_cursor back as cursor to get the following page.
 // @Tags Todos
 // @Security BearerAuth
 // @Accept json
 // @Produce json
-// @Success 200 {array} models.Todo
+// @Param completed query bool false "Filter by completion state"
+// @Param status_id query int false "Only todos in this status"
+// @Param project_id query int false "Only todos in this project"
+// @Param assignee query string false "Only todos assigned to this user id, or to the current user with me"
+// @Param due query string false "Filter by due date: overdue (open and past due), today or upcoming (due after today)" Enums(overdue, today, upcoming)
+// @Param tz query string false "IANA time zone that defines today, e.g. Europe/Berlin (default UTC)"
+// @Param tag query []string false "Only todos with these tag names (repeat the parameter for several)" collectionFormat(multi)
+// @Param tag_match query string false "Whether todos need all the given tags or any of them (default all)" Enums(all, any)
+// @Param sort query string false "Sort field (default rank, the order set with POST /todos/{id}/move)" Enums(rank, id, title, created, due)
+// @Param order query string false "Sort direction (default asc)" Enums(asc, desc)
+// @Param limit query int false "Page size, 1 to 200 (default 50)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} models.TodoPage
+// @Failure 400 {string} string "Invalid request"
 // @Failure 401 {string} string "Unauthorized"
 // @Router /todos [get]
-func GetTodos(w http.ResponseWriter, r *http.Request) {
+func (h *TodoHandler) GetTodos(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("user_id")
 	roleValue := r.Context().Value("role").(string)
 
@@ -33,59 +71,122 @@ func GetTodos(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Unauthorized: Invalid user ID", http.StatusUnauthorized)
 		return
 	}
-	var query string
-	var rows *sql.Rows
-	var err error
-	completedParam := r.URL.Query().Get("completed")
-	if roleValue == "admin" {
-		query = "SELECT * FROM todos"
-	} else {
-		query = "select * from todos where user_id = $1"
+	var filter store.TodoFilter
+	if roleValue != "admin" {
+		filter.VisibleTo = id
 	}
-	if completedParam == "true" {
-		query += "and completed = true"
-	} else if completedParam == "false" {
-		query += "and completed = false"
-		rows, err = db.DB.Query(query, id)
+	if !parseTodoFilter(w, r, &filter) || !parseTodoPage(w, r, &filter) {
+		return
 	}
-	if roleValue == "admin" {
-		rows, err = db.DB.Query(query)
-	} else {
-		rows, err = db.DB.Query(query, userID)
+	writeTodoPage(w, r, h.Todos, filter)
+}
+
+// GetTodo retrieves a single todo
+// @Summary Get Todo
+// @Description Retrieve one todo (users can see their own todos and those shared with them, admins can see any)
+// @Tags Todos
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Success 200 {object} models.Todo
+// @Header 200 {string} ETag "The todo's version"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id} [get]
+func (h *TodoHandler) GetTodo(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	role := r.Context().Value("role").(string)
+
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	todo, ok := readableTodo(w, r, h.Todos, h.Shares, id, userID, role)
+	if !ok {
+		return
+	}
+	setTodoETag(w, todo)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(todo)
+}
+
+// GetSubtasks returns a todo's subtasks as a tree
+// @Summary Get Subtasks
+// @Description List the subtasks of a todo at every depth, each with its own subtasks nested under it (users can see their own todos and those shared with them, admins can see any)
+// @Tags Todos
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Success 200 {array} models.Subtask
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Todo not found"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id}/subtasks [get]
+func (h *TodoHandler) GetSubtasks(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+	role := r.Context().Value("role").(string)
+
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := readableTodo(w, r, h.Todos, h.Shares, id, userID, role); !ok {
+		return
 	}
+	subtasks, err := h.Todos.ListSubtasks(r.Context(), id)
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
-
-	defer rows.Close()
-	var todos []models.Todo
-	for rows.Next() {
-		var todo models.Todo
-		if err := rows.Scan(&todo.ID, &todo.Title, &todo.Completed, &todo.UserId); err != nil {
-			http.Error(w, "Error scanning row:", http.StatusInternalServerError)
-			return
+	// Subtasks in the trash hang under todos in the trash, so dropping them
+	// leaves the tree intact.
+	live := subtasks[:0]
+	for _, t := range subtasks {
+		if t.DeletedAt == nil {
+			live = append(live, t)
 		}
-		todos = append(todos, todo)
 	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(todos)
+	json.NewEncoder(w).Encode(subtaskTree(id, live))
+}
+
+// subtaskTree nests subtasks, all descendants of parentID ordered by id,
+// under their parents and returns the direct children of parentID.
+func subtaskTree(parentID int, subtasks []models.Todo) []models.Subtask {
+	children := map[int][]models.Todo{}
+	for _, t := range subtasks {
+		children[*t.ParentID] = append(children[*t.ParentID], t)
+	}
+	var build func(id int) []models.Subtask
+	build = func(id int) []models.Subtask {
+		nodes := []models.Subtask{}
+		for _, t := range children[id] {
+			nodes = append(nodes, models.Subtask{Todo: t, Subtasks: build(t.ID)})
+		}
+		return nodes
+	}
+	return build(parentID)
 }
 
 // CreateTodo creates a new todo
 // @Summary Create Todo
-// @Description Create a new todo (only authenticated users)
+// @Description Create a new todo (only authenticated users). A todo with an rrule recurs: completing it creates the next occurrence.
 // @Tags Todos
 // @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param todo body models.TodoModel true "Todo data"
-// @Success 201 {object} models.TodoModel
+// @Success 201 {object} models.Todo
+// @Header 201 {string} ETag "The todo's version"
 // @Failure 400 {string} string "Invalid request"
 // @Failure 401 {string} string "Unauthorized"
 // @Failure 500 {string} string "Server error"
-// @Router /todos/create [post]
-func CreateTodo(w http.ResponseWriter, r *http.Request) {
+// @Router /todos [post]
+func (h *TodoHandler) CreateTodo(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("user_id").(int)
 
 	var todo models.TodoModel
@@ -99,48 +200,86 @@ func CreateTodo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stmt, err := db.DB.Prepare("insert into todos(title, user_id) values($1, $2)")
-	if err != nil {
-		fmt.Println(err)
-		http.Error(w, "Database error", http.StatusInternalServerError)
+	projectID, ok := h.todoProject(w, r, todo.ProjectID, userID)
+	if !ok {
 		return
 	}
-	defer stmt.Close()
-	_, err = stmt.Exec(todo.Title, userID)
-	if err != nil {
+	if !h.checkParent(w, r, todo.ParentID, 0, userID) {
+		return
+	}
+	created := models.Todo{
+		Title:      todo.Title,
+		StatusID:   todo.StatusID,
+		Priority:   todo.Priority,
+		UserId:     userID,
+		AssigneeID: todo.AssigneeID,
+		ProjectID:  projectID,
+		ParentID:   todo.ParentID,
+		DueAt:      todo.DueAt,
+		RemindAt:   todo.RemindAt,
+		RRule:      todo.RRule,
+		Timezone:   todo.Timezone,
+		Tags:       []models.Tag{},
+	}
+	if !checkPriority(w, &created) || !h.checkAssignee(w, r, created) {
+		return
+	}
+	if !h.applyStatus(w, r, models.Todo{}, &created) {
+		return
+	}
+	if !h.applyRecurrence(w, r, models.Todo{}, &created, false) {
+		return
+	}
+	if err := h.Todos.CreateTodo(r.Context(), &created); err != nil {
 		http.Error(w, "Error creating with todo", http.StatusInternalServerError)
 		return
 	}
+	h.notifyAssigned(models.Todo{}, created, userID)
+	// Reloading fills in what the list and get endpoints show, such as the
+	// status name and blockers.
+	created, err := h.Todos.GetTodo(r.Context(), created.ID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/todos/%d", created.ID))
+	setTodoETag(w, created)
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(todo)
+	json.NewEncoder(w).Encode(created)
 }
 
-// UpdateTodo updates an existing todo
+// UpdateTodo replaces the editable fields of an existing todo
 // @Summary Update Todo
-// @Description Update a todo (users can update only their own todos)
+// @Description Replace a todo's title and completion state (users can update their own todos and those shared with them as editor)
 // @Tags Todos
 // @Security BearerAuth
 // @Accept json
 // @Produce json
+// @Param id path int true "Todo ID"
+// @Param scope query string false "For a recurring todo: change only this occurrence (default) or future ones too" Enums(this, future)
+// @Param respect_blockers query bool false "Refuse to complete the todo while todos blocking it are open"
 // @Param todo body models.UpdateTodoModel true "Updated todo data"
-// @Param id query int true "Todo ID"
-// @Success 200 {object} models.UpdateTodoModel
+// @Param If-Match header string false "ETag of the version the change is based on"
+// @Success 200 {object} models.Todo
+// @Header 200 {string} ETag "The todo's new version"
 // @Failure 400 {string} string "Invalid request"
 // @Failure 401 {string} string "Unauthorized"
 // @Failure 403 {string} string "Forbidden"
+// @Failure 409 {string} string "Occurrence already exists, or the todo is blocked"
+// @Failure 412 {object} models.TodoConflict "The todo has changed since that version"
 // @Failure 500 {string} string "Server error"
-// @Router /todos/update [put]
-func UpdateTodo(w http.ResponseWriter, r *http.Request) {
+// @Router /todos/{id} [put]
+func (h *TodoHandler) UpdateTodo(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value("user_id").(int)
 	if !ok {
 		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
 		return
 	}
 
-	id := r.URL.Query().Get("id")
-	if id == "" {
-		http.Error(w, "Id is required", http.StatusBadRequest)
+	id, ok := todoID(w, r)
+	if !ok {
 		return
 	}
 
@@ -150,75 +289,208 @@ func UpdateTodo(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid request payload", http.StatusBadRequest)
 		return
 	}
-	var exists bool
-	err := db.DB.QueryRow("select exists(select 1 from todos where id = $1 and user_id = $2)", id, userID).Scan(&exists)
-	if err != nil || !exists {
-		http.Error(w, "Todo not found or you do not have access", http.StatusForbidden)
+	future, ok := editScope(w, r)
+	if !ok {
+		return
+	}
+	existing, ok := editableTodo(w, r, h.Todos, h.Shares, id, userID)
+	if !ok || !checkIfMatch(w, r, existing) {
 		return
 	}
 
-	stmt, err := db.DB.Prepare("Update todos set title = $1, completed = $2 where id = $3 and user_id = $4")
-	if err != nil {
-		fmt.Println(err)
-		http.Error(w, "Databse error", http.StatusInternalServerError)
+	updated := existing
+	updated.Title = todo.Title
+	updated.Completed = todo.Completed
+	if !h.applyStatus(w, r, existing, &updated) {
+		return
+	}
+	if !checkBlockers(w, r, existing, updated) {
+		return
+	}
+	if !h.applyRecurrence(w, r, existing, &updated, future) {
+		return
+	}
+	if !h.saveTodo(w, r, existing, &updated) {
 		return
 	}
-	defer stmt.Close()
-	_, err = stmt.Exec(todo.Title, todo.Completed, id, userID)
+	// Reload to pick up subtasks completed along with the todo.
+	reloaded, err := h.Todos.GetTodo(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Error with updating todo", http.StatusInternalServerError)
+		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
+	setTodoETag(w, reloaded)
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(todo)
+	json.NewEncoder(w).Encode(reloaded)
 }
 
-// DeleteTodo deletes a todo
-// @Summary Delete Todo
-// @Description Delete a todo (users can delete only their own todos, admins can delete any)
+// PatchTodo partially updates an existing todo
+// @Summary Patch Todo
+// @Description Change only the supplied fields of a todo using a JSON Merge Patch (RFC 7396). Users can patch their own todos and those shared with them as editor.
 // @Tags Todos
 // @Security BearerAuth
-// @Accept json
+// @Accept application/merge-patch+json
 // @Produce json
-// @Param id query int true "Todo ID"
-// @Success 200 {string} string "Todo deleted"
+// @Param id path int true "Todo ID"
+// @Param scope query string false "For a recurring todo: change only this occurrence (default) or future ones too" Enums(this, future)
+// @Param respect_blockers query bool false "Refuse to complete the todo while todos blocking it are open"
+// @Param patch body models.PatchTodoModel true "Fields to change"
+// @Param If-Match header string false "ETag of the version the change is based on"
+// @Success 200 {object} models.Todo
+// @Header 200 {string} ETag "The todo's new version"
 // @Failure 400 {string} string "Invalid request"
 // @Failure 401 {string} string "Unauthorized"
 // @Failure 403 {string} string "Forbidden"
+// @Failure 415 {string} string "Unsupported media type"
+// @Failure 409 {string} string "Occurrence already exists, or the todo is blocked"
+// @Failure 412 {object} models.TodoConflict "The todo has changed since that version"
 // @Failure 500 {string} string "Server error"
-// @Router /todos/delete [delete]
-func DeleteTodo(w http.ResponseWriter, r *http.Request) {
+// @Router /todos/{id} [patch]
+func (h *TodoHandler) PatchTodo(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("user_id").(int)
 
-	id := r.URL.Query().Get("id")
-	if id == "" {
-		http.Error(w, "Id is required", http.StatusBadRequest)
+	id, ok := todoID(w, r)
+	if !ok {
 		return
 	}
-	var exists bool
-	err := db.DB.QueryRow("Select Exists(select 1 from todos where id = $1 and user_id = $2)", id, userID).Scan(&exists)
-	if err != nil || !exists {
-		http.Error(w, "Todo not found or you do not have access", http.StatusForbidden)
+	future, ok := editScope(w, r)
+	if !ok {
+		return
+	}
+	patch, err := decodeMergePatch(r, todoPatchFields)
+	if errors.Is(err, errUnsupportedMediaType) {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	} else if err != nil {
+		http.Error(w, "Invalid request payload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	existing, ok := editableTodo(w, r, h.Todos, h.Shares, id, userID)
+	if !ok || !checkIfMatch(w, r, existing) {
+		return
+	}
+	h.applyTodoPatch(w, r, existing, patch, future)
+}
+
+// applyTodoPatch applies a merge patch to existing, checks and saves the
+// result, and responds with the updated todo. The caller has checked that
+// the current user may edit existing.
+func (h *TodoHandler) applyTodoPatch(w http.ResponseWriter, r *http.Request, existing models.Todo, patch map[string]any, future bool) {
+	userID := r.Context().Value("user_id").(int)
+
+	todo := existing
+	if err := applyMergePatch(&todo, patch); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	todo.ID = existing.ID
+	todo.UserId = existing.UserId
+	if todo.Title == "" {
+		http.Error(w, "Title is required", http.StatusBadRequest)
+		return
+	}
+	if !checkPriority(w, &todo) {
+		return
+	}
+	if todo.ProjectID != existing.ProjectID {
+		projectID, ok := h.todoProject(w, r, todo.ProjectID, existing.UserId)
+		if !ok {
+			return
+		}
+		todo.ProjectID = projectID
+	}
+	if !sameID(todo.ParentID, existing.ParentID) && !h.checkParent(w, r, todo.ParentID, existing.ID, existing.UserId) {
+		return
+	}
+	if !sameID(todo.AssigneeID, existing.AssigneeID) && !h.checkAssignee(w, r, todo) {
+		return
+	}
+	if !sameTime(todo.RemindAt, existing.RemindAt) {
+		// A new reminder time means a new reminder to deliver.
+		todo.RemindedAt = nil
+	}
+	if !h.applyStatus(w, r, existing, &todo) {
+		return
+	}
+	if !checkBlockers(w, r, existing, todo) {
+		return
+	}
+	if !h.applyRecurrence(w, r, existing, &todo, future) {
+		return
+	}
+	if !h.saveTodo(w, r, existing, &todo) {
 		return
 	}
-	stmt, err := db.DB.Prepare("Delete from todos where id = $1 and user_id = $2")
+	h.notifyAssigned(existing, todo, userID)
+	// Reload to pick up subtasks completed along with the todo.
+	todo, err := h.Todos.GetTodo(r.Context(), existing.ID)
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
-	defer stmt.Close()
+	setTodoETag(w, todo)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(todo)
+}
 
-	_, err = stmt.Exec(id, userID)
-	if err != nil {
+// DeleteTodo moves a todo to the trash
+// @Summary Delete Todo
+// @Description Move a todo and its subtasks to the owner's trash, from where they can be restored until the trash is purged (users can delete their own todos and those shared with them as editor)
+// @Tags Todos
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param scope query string false "For a recurring todo: skip only this occurrence (default) or end the series" Enums(this, future)
+// @Param If-Match header string false "ETag of the version the change is based on"
+// @Success 204 {string} string "Todo deleted"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Todo not found"
+// @Failure 412 {object} models.TodoConflict "The todo has changed since that version"
+// @Failure 500 {string} string "Server error"
+// @Router /todos/{id} [delete]
+func (h *TodoHandler) DeleteTodo(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	future, ok := editScope(w, r)
+	if !ok {
+		return
+	}
+	todo, ok := editableTodo(w, r, h.Todos, h.Shares, id, userID)
+	if !ok || !checkIfMatch(w, r, todo) {
+		return
+	}
+	err := h.Todos.DeleteTodo(r.Context(), id, todo.Version)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Todo not found", http.StatusNotFound)
+		return
+	} else if errors.Is(err, store.ErrVersionConflict) {
+		writeStoredConflict(w, r, h.Todos, id)
+		return
+	} else if err != nil {
 		http.Error(w, "Error with deleting todo", http.StatusInternalServerError)
 		return
 	}
+	if !todo.Completed && !future {
+		// Deleting only this occurrence skips it; the series goes on.
+		if err := h.scheduleNext(r.Context(), todo); err != nil {
+			http.Error(w, "Todo deleted, but its next occurrence could not be created", http.StatusInternalServerError)
+			return
+		}
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
 // DeleteAllTodos godoc
 // @Summary Delete Any Todo (Admin Only)
-// @Description Admin can delete any todo
+// @Description Admin can move any todo to its owner's trash
 // @Tags Admin
 // @Security BearerAuth
 // @Accept json
@@ -228,18 +500,32 @@ func DeleteTodo(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {string} string "Invalid request"
 // @Failure 401 {string} string "Unauthorized"
 // @Failure 403 {string} string "Forbidden (Admins only)"
+// @Failure 404 {string} string "Todo not found"
+// @Failure 412 {object} models.TodoConflict "The todo changed while it was being deleted"
 // @Failure 500 {string} string "Server error"
 // @Router /admin/todos [delete]
-func DeleteAllTodos(w http.ResponseWriter, r *http.Request) {
+func (h *TodoHandler) DeleteAllTodos(w http.ResponseWriter, r *http.Request) {
 	role := r.Context().Value("role").(string)
-	id := r.URL.Query().Get("id")
 	if role != "admin" {
 		http.Error(w, "Forbidden: only Admin can delete all todos", http.StatusForbidden)
 		return
 	}
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
 
-	_, err := db.DB.Exec("delete from todos where id = $1", id)
-	if err != nil {
+	todo, err := h.Todos.GetTodo(r.Context(), id)
+	if err == nil {
+		err = h.Todos.DeleteTodo(r.Context(), id, todo.Version)
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Todo not found", http.StatusNotFound)
+		return
+	} else if errors.Is(err, store.ErrVersionConflict) {
+		writeStoredConflict(w, r, h.Todos, id)
+		return
+	} else if err != nil {
 		http.Error(w, "Failed to delete al todos", http.StatusInternalServerError)
 		return
 	}
@@ -248,52 +534,267 @@ func DeleteAllTodos(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"message": "All todos successfully deleted"})
 }
 
-// Get all users
-// @Summary Get all users (Admin Only)
-// @Description Admin can get all users
-// @Tags Admin
-// @Security BearerAuth
-// @Accept json
-// @Produce json
-// @Success 200 {string} string "Get Users"
-// @Failure 400 {string} string "Invalid request"
-// @Failure 401 {string} string "Unauthorized"
-// @Failure 403 {string} string "Forbidden (Admins only)"
-// @Failure 500 {string} string "Server error"
-// @Router /admin/getallusers [get]
-func GetAllUsers(w http.ResponseWriter, r *http.Request) {
-	role := r.Context().Value("role").(string)
-	if role != "admin" {
-		http.Error(w, "Only admin can see all users", http.StatusForbidden)
-		return
+// parseTodoFilter adds the filters given in the query string to filter,
+// writing a 400 and returning false when one of them is invalid.
+func parseTodoFilter(w http.ResponseWriter, r *http.Request, filter *store.TodoFilter) bool {
+	query := r.URL.Query()
+	if raw := query.Get("completed"); raw != "" {
+		completed, err := strconv.ParseBool(raw)
+		if err != nil {
+			http.Error(w, "completed must be true or false", http.StatusBadRequest)
+			return false
+		}
+		filter.Completed = &completed
 	}
-	var users []models.AllUser
-	rows, err := db.DB.Query("select id, username, role from users")
-	if err != nil {
-		http.Error(w, "Error with fetching all users", http.StatusInternalServerError)
-		return
+	if raw := query.Get("status_id"); raw != "" {
+		statusID, err := strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, "Invalid status_id", http.StatusBadRequest)
+			return false
+		}
+		filter.StatusID = statusID
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var id int
-		var username string
-		var role string
-		err := rows.Scan(&id, &username, &role)
+	if raw := query.Get("project_id"); raw != "" {
+		projectID, err := strconv.Atoi(raw)
 		if err != nil {
-			http.Error(w, "Error with fetching users", http.StatusInternalServerError)
-			return
+			http.Error(w, "Invalid project_id", http.StatusBadRequest)
+			return false
 		}
-		user := models.AllUser{
-			ID:       id,
-			Username: username,
-			Role:     role,
+		filter.ProjectID = projectID
+	}
+	if raw := query.Get("assignee"); raw != "" {
+		if raw == "me" {
+			filter.AssigneeID = r.Context().Value("user_id").(int)
+		} else if assigneeID, err := strconv.Atoi(raw); err == nil {
+			filter.AssigneeID = assigneeID
+		} else {
+			http.Error(w, "assignee must be me or a user id", http.StatusBadRequest)
+			return false
 		}
-		users = append(users, user)
 	}
-	if err := rows.Err(); err != nil {
-		http.Error(w, "Error reading rows", http.StatusInternalServerError)
-		return
+	if tags := query["tag"]; len(tags) > 0 {
+		filter.Tags = tags
+		switch query.Get("tag_match") {
+		case "", "all":
+			filter.MatchAllTags = true
+		case "any":
+		default:
+			http.Error(w, "tag_match must be all or any", http.StatusBadRequest)
+			return false
+		}
 	}
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(users)
+	if due := query.Get("due"); due != "" {
+		loc, err := time.LoadLocation(query.Get("tz"))
+		if err != nil {
+			http.Error(w, "Invalid tz", http.StatusBadRequest)
+			return false
+		}
+		if err := applyDueFilter(filter, due, time.Now().In(loc)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return false
+		}
+	}
+	return true
+}
+
+// applyDueFilter narrows filter to todos that are overdue, due today or due
+// after today, relative to now and its time zone.
+func applyDueFilter(filter *store.TodoFilter, due string, now time.Time) error {
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	tomorrow := startOfDay.AddDate(0, 0, 1)
+	switch due {
+	case "overdue":
+		completed := false
+		filter.Completed = &completed
+		filter.DueBefore = &now
+	case "today":
+		filter.DueFrom = &startOfDay
+		filter.DueBefore = &tomorrow
+	case "upcoming":
+		filter.DueFrom = &tomorrow
+	default:
+		return errors.New("due must be overdue, today or upcoming")
+	}
+	return nil
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// todoID reads the todo id from the {id} path segment, or from the "id" query
+// parameter used by the deprecated routes, writing a 400 when it is missing or
+// not a number.
+func todoID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	raw := r.PathValue("id")
+	if raw == "" {
+		raw = r.URL.Query().Get("id")
+	}
+	if raw == "" {
+		http.Error(w, "Id is required", http.StatusBadRequest)
+		return 0, false
+	}
+	id, err := strconv.Atoi(raw)
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// todoProject resolves the project a todo of userID should be placed in: the
+// user's Inbox when projectID is zero, otherwise projectID after checking that
+// it is one of the user's active projects. It writes a 400 when it is not.
+func (h *TodoHandler) todoProject(w http.ResponseWriter, r *http.Request, projectID, userID int) (int, bool) {
+	var project models.Project
+	var err error
+	if projectID == 0 {
+		project, err = h.Projects.GetInbox(r.Context(), userID)
+	} else {
+		project, err = h.Projects.GetProject(r.Context(), projectID)
+	}
+	if errors.Is(err, store.ErrNotFound) || (err == nil && project.UserID != userID) {
+		http.Error(w, "Project not found", http.StatusBadRequest)
+		return 0, false
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return 0, false
+	}
+	if project.Archived {
+		http.Error(w, "Project is archived", http.StatusBadRequest)
+		return 0, false
+	}
+	return project.ID, true
+}
+
+// saveTodo stores the edited todo and, when the edit completes an occurrence
+// of a recurring todo, creates the next occurrence. It writes an error
+// response and returns false on failure, including a 412 when the todo was
+// changed by another request since existing was loaded.
+func (h *TodoHandler) saveTodo(w http.ResponseWriter, r *http.Request, existing models.Todo, todo *models.Todo) bool {
+	err := h.Todos.UpdateTodo(r.Context(), todo)
+	if errors.Is(err, store.ErrVersionConflict) {
+		writeStoredConflict(w, r, h.Todos, todo.ID)
+		return false
+	} else if errors.Is(err, store.ErrOccurrenceExists) {
+		http.Error(w, "Another occurrence of this todo is already due at that time", http.StatusConflict)
+		return false
+	} else if err != nil {
+		http.Error(w, "Error with updating todo", http.StatusInternalServerError)
+		return false
+	}
+	if todo.Completed && !existing.Completed {
+		if err := h.scheduleNext(r.Context(), *todo); err != nil {
+			http.Error(w, "Todo updated, but its next occurrence could not be created", http.StatusInternalServerError)
+			return false
+		}
+	}
+	return true
+}
+
+// checkParent checks that parentID, when set, is a todo of userID that the
+// todo id (zero for a new todo) can be placed under without creating a
+// cycle. It writes a 400 when it is not.
+func (h *TodoHandler) checkParent(w http.ResponseWriter, r *http.Request, parentID *int, id, userID int) bool {
+	if parentID == nil {
+		return true
+	}
+	parent, err := h.Todos.GetTodo(r.Context(), *parentID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && parent.UserId != userID) {
+		http.Error(w, "Parent todo not found", http.StatusBadRequest)
+		return false
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return false
+	}
+	if id == 0 {
+		return true
+	}
+	subtasks, err := h.Todos.ListSubtasks(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return false
+	}
+	for _, t := range append(subtasks, models.Todo{ID: id}) {
+		if t.ID == *parentID {
+			http.Error(w, "A todo cannot be moved under itself or one of its subtasks", http.StatusBadRequest)
+			return false
+		}
+	}
+	return true
+}
+
+// sameID reports whether two optional ids are equal.
+func sameID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// readableTodo loads the todo with the given id for a reader with userID and
+// role: admins can read any todo, users their own and those shared with them.
+func readableTodo(w http.ResponseWriter, r *http.Request, todos store.TodoStore, shares store.ShareStore, id, userID int, role string) (models.Todo, bool) {
+	if role != "admin" {
+		return sharedTodo(w, r, todos, shares, id, userID, false)
+	}
+	todo, err := todos.GetTodo(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Todo not found", http.StatusNotFound)
+		return models.Todo{}, false
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return models.Todo{}, false
+	}
+	return todo, true
+}
+
+// editableTodo loads the todo with the given id and checks that userID owns
+// it or was granted a share of it as editor, writing a 403 when not.
+func editableTodo(w http.ResponseWriter, r *http.Request, todos store.TodoStore, shares store.ShareStore, id, userID int) (models.Todo, bool) {
+	return sharedTodo(w, r, todos, shares, id, userID, true)
+}
+
+// sharedTodo loads the todo with the given id and checks that userID owns it
+// or was granted a share of it, as editor when edit is set. It writes a 403
+// when the todo does not exist or the user has no such access.
+func sharedTodo(w http.ResponseWriter, r *http.Request, todos store.TodoStore, shares store.ShareStore, id, userID int, edit bool) (models.Todo, bool) {
+	todo, err := todos.GetTodo(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Todo not found or you do not have access", http.StatusForbidden)
+		return models.Todo{}, false
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return models.Todo{}, false
+	}
+	if todo.UserId == userID {
+		return todo, true
+	}
+	role, err := shares.ShareRole(r.Context(), id, userID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return models.Todo{}, false
+	}
+	if role == models.ShareEditor || (role == models.ShareViewer && !edit) {
+		return todo, true
+	}
+	http.Error(w, "Todo not found or you do not have access", http.StatusForbidden)
+	return models.Todo{}, false
+}
+
+// ownTodo loads the todo with the given id and checks that it belongs to
+// userID, writing a 403 when it does not exist or belongs to someone else.
+func ownTodo(w http.ResponseWriter, r *http.Request, todos store.TodoStore, id, userID int) (models.Todo, bool) {
+	todo, err := todos.GetTodo(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && todo.UserId != userID) {
+		http.Error(w, "Todo not found or you do not have access", http.StatusForbidden)
+		return models.Todo{}, false
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return models.Todo{}, false
+	}
+	return todo, true
 }
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Anwarjondev/todo-api-go/blobs"
+	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/store"
+)
+
+// TrashHandler serves the endpoints for the current user's deleted todos.
+// Only the owner of a todo can see, restore or purge it once it is in the
+// trash.
+type TrashHandler struct {
+	Trash store.TrashStore
+	Todos store.TodoStore
+	Blobs blobs.BlobStore
+}
+
+func NewTrashHandler(trash store.TrashStore, todos store.TodoStore, blobs blobs.BlobStore) *TrashHandler {
+	return &TrashHandler{Trash: trash, Todos: todos, Blobs: blobs}
+}
+
+// GetTrash lists the deleted todos
+// @Summary Get Trash
+// @Description List the current user's todos in the trash, most recently deleted first. Subtasks deleted with a todo are listed too. Todos are purged for good once they have been in the trash longer than the retention period.
+// @Tags Trash
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.Todo
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Server error"
+// @Router /trash [get]
+func (h *TrashHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	todos, err := h.Trash.ListTrash(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if todos == nil {
+		todos = []models.Todo{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(todos)
+}
+
+// RestoreTodo takes a todo out of the trash
+// @Summary Restore Todo
+// @Description Restore one of the current user's deleted todos, together with the subtasks deleted along with it. A subtask can only be restored once its parent is.
+// @Tags Trash
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Success 200 {object} models.Todo
+// @Header 200 {string} ETag "The todo's version"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Todo not found in the trash"
+// @Failure 409 {string} string "The parent todo is in the trash"
+// @Failure 500 {string} string "Server error"
+// @Router /trash/{id}/restore [post]
+func (h *TrashHandler) RestoreTodo(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	todo, ok := h.trashedTodo(w, r, userID)
+	if !ok {
+		return
+	}
+	err := h.Trash.RestoreTodo(r.Context(), todo.ID)
+	if errors.Is(err, store.ErrParentTrashed) {
+		http.Error(w, "Restore the parent todo first", http.StatusConflict)
+		return
+	} else if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Todo not found in the trash", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if todo, err = h.Todos.GetTodo(r.Context(), todo.ID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	setTodoETag(w, todo)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(todo)
+}
+
+// PurgeTodo permanently deletes a todo in the trash
+// @Summary Purge Todo
+// @Description Permanently delete one of the current user's todos in the trash, with its subtasks, comments and attachments. This cannot be undone.
+// @Tags Trash
+// @Security BearerAuth
+// @Param id path int true "Todo ID"
+// @Success 204 {string} string "Todo purged"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Todo not found in the trash"
+// @Failure 500 {string} string "Server error"
+// @Router /trash/{id} [delete]
+func (h *TrashHandler) PurgeTodo(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int)
+
+	todo, ok := h.trashedTodo(w, r, userID)
+	if !ok {
+		return
+	}
+	keys, err := h.Trash.PurgeTodo(r.Context(), todo.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Todo not found in the trash", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	for _, key := range keys {
+		deleteBlob(r.Context(), h.Blobs, key)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// trashedTodo loads the todo in the trash named in the path, answering 404
+// for todos of other users.
+func (h *TrashHandler) trashedTodo(w http.ResponseWriter, r *http.Request, userID int) (models.Todo, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid todo id", http.StatusBadRequest)
+		return models.Todo{}, false
+	}
+	todo, err := h.Trash.GetTrashedTodo(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && todo.UserId != userID) {
+		http.Error(w, "Todo not found in the trash", http.StatusNotFound)
+		return models.Todo{}, false
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return models.Todo{}, false
+	}
+	return todo, true
+}
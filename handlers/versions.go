@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/store"
+)
+
+// todoETag returns the entity tag of a todo at the given version.
+func todoETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// setTodoETag sends the version of todo as the ETag of the response.
+func setTodoETag(w http.ResponseWriter, todo models.Todo) {
+	w.Header().Set("ETag", todoETag(todo.Version))
+}
+
+// checkIfMatch compares the If-Match header, when the request has one, with
+// the current version of todo. When none of the listed tags match it writes
+// a 412 carrying the current todo and returns false.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, todo models.Todo) bool {
+	values := r.Header.Values("If-Match")
+	if len(values) == 0 {
+		return true
+	}
+	current := todoETag(todo.Version)
+	for _, tag := range strings.Split(strings.Join(values, ","), ",") {
+		// Proxies that compress responses turn the ETag into a weak one;
+		// the version it carries is still the one the client read.
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	writeVersionConflict(w, todo)
+	return false
+}
+
+// writeVersionConflict answers a change based on an outdated version with
+// 412 and the current state of todo, for the client to merge and retry.
+func writeVersionConflict(w http.ResponseWriter, todo models.Todo) {
+	setTodoETag(w, todo)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusPreconditionFailed)
+	json.NewEncoder(w).Encode(models.TodoConflict{
+		Message: fmt.Sprintf("The todo has changed; it is now at version %d", todo.Version),
+		Version: todo.Version,
+		Todo:    todo,
+	})
+}
+
+// writeStoredConflict answers a change the store refused with
+// ErrVersionConflict, loading the todo again to send its current state.
+func writeStoredConflict(w http.ResponseWriter, r *http.Request, todos store.TodoStore, id int) {
+	current, err := todos.GetTodo(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	writeVersionConflict(w, current)
+}
@@ -8,11 +8,21 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"log"
 	"net/http"
+	"os"
+	"time"
 
+	"github.com/Anwarjondev/todo-api-go/blobs"
 	"github.com/Anwarjondev/todo-api-go/db"
 	_ "github.com/Anwarjondev/todo-api-go/docs"
+	"github.com/Anwarjondev/todo-api-go/rank"
+	"github.com/Anwarjondev/todo-api-go/reminders"
 	"github.com/Anwarjondev/todo-api-go/routes"
+	"github.com/Anwarjondev/todo-api-go/store"
+	"github.com/Anwarjondev/todo-api-go/trash"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
@@ -32,20 +42,143 @@ import (
 // @name Authorization
 // @description JWT Authorization header using the Bearer scheme. Example: "Bearer {token}"
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
 
-	db.InitDB()
+	s := openStore()
+	if len(os.Args) > 1 && os.Args[1] == "bootstrap-admin" {
+		runBootstrapAdmin(s)
+		return
+	}
+	if os.Getenv("ADMIN_USERNAME") != "" {
+		created, err := bootstrapAdmin(context.Background(), s)
+		if err != nil {
+			log.Fatalf("Failed to bootstrap admin: %v", err)
+		}
+		if created {
+			log.Printf("Bootstrapped admin %s", os.Getenv("ADMIN_USERNAME"))
+		}
+	}
+
+	reminderNotifier, err := newNotifier("REMINDER")
+	if err != nil {
+		log.Fatalf("Failed to configure reminders: %v", err)
+	}
+	if reminderNotifier != nil {
+		dispatcher := reminders.NewDispatcher(s, reminderNotifier)
+		if interval := os.Getenv("REMINDER_INTERVAL"); interval != "" {
+			if dispatcher.Interval, err = time.ParseDuration(interval); err != nil || dispatcher.Interval <= 0 {
+				log.Fatalf("Invalid REMINDER_INTERVAL %q", interval)
+			}
+		}
+		go dispatcher.Run(context.Background())
+	}
+
+	rebalancer := rank.NewRebalancer(s)
+	if interval := os.Getenv("RANK_REBALANCE_INTERVAL"); interval != "" {
+		if rebalancer.Interval, err = time.ParseDuration(interval); err != nil || rebalancer.Interval <= 0 {
+			log.Fatalf("Invalid RANK_REBALANCE_INTERVAL %q", interval)
+		}
+	}
+	go rebalancer.Run(context.Background())
+
+	files, err := newBlobStore()
+	if err != nil {
+		log.Fatalf("Failed to open attachment storage: %v", err)
+	}
+
+	purger := trash.NewPurger(s, files)
+	if retention := os.Getenv("TRASH_RETENTION"); retention != "" {
+		if purger.Retention, err = time.ParseDuration(retention); err != nil || purger.Retention < 0 {
+			log.Fatalf("Invalid TRASH_RETENTION %q", retention)
+		}
+	}
+	if interval := os.Getenv("TRASH_PURGE_INTERVAL"); interval != "" {
+		if purger.Interval, err = time.ParseDuration(interval); err != nil || purger.Interval <= 0 {
+			log.Fatalf("Invalid TRASH_PURGE_INTERVAL %q", interval)
+		}
+	}
+	go purger.Run(context.Background())
+
+	assignments, err := newNotifier("ASSIGNMENT")
+	if err != nil {
+		log.Fatalf("Failed to configure assignment notifications: %v", err)
+	}
+	if assignments == nil {
+		log.Printf("Assignment notifications are off")
+	}
 
 	mux := http.NewServeMux()
-	routes.SetupRoutes(mux)
+	routes.SetupRoutes(mux, s, files, assignments)
 	mux.Handle("/swagger/", httpSwagger.WrapHandler)
 
 	http.ListenAndServe(":8080", enableCORS(mux))
 }
+
+// notifier delivers both reminders and assignment notifications.
+type notifier interface {
+	reminders.Notifier
+	reminders.AssignmentNotifier
+}
+
+// newNotifier returns the notifier selected by <event>_NOTIFIER, where event
+// is REMINDER or ASSIGNMENT: "log" (the default), "webhook" (POSTs to
+// <event>_WEBHOOK_URL) or "off", for which it returns nil.
+func newNotifier(event string) (notifier, error) {
+	switch kind := notifierEnv(event, "NOTIFIER"); kind {
+	case "", "log":
+		return reminders.LogNotifier{}, nil
+	case "webhook":
+		url := notifierEnv(event, "WEBHOOK_URL")
+		if url == "" {
+			return nil, fmt.Errorf("%s_WEBHOOK_URL is required for the webhook notifier", event)
+		}
+		return reminders.NewWebhookNotifier(url), nil
+	case "off":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown %s_NOTIFIER %q", event, kind)
+	}
+}
+
+// notifierEnv returns the environment variable <event>_<name>. Assignment
+// notifications go where reminders do unless configured apart, so an unset
+// ASSIGNMENT_ variable falls back to the REMINDER_ one.
+func notifierEnv(event, name string) string {
+	if value := os.Getenv(event + "_" + name); value != "" {
+		return value
+	}
+	return os.Getenv("REMINDER_" + name)
+}
+
+// newBlobStore returns the storage for attachment files: a directory named by
+// ATTACHMENTS_DIR, "attachments" by default.
+func newBlobStore() (blobs.BlobStore, error) {
+	dir := os.Getenv("ATTACHMENTS_DIR")
+	if dir == "" {
+		dir = "attachments"
+	}
+	return blobs.NewFileStore(dir)
+}
+
+// openStore returns the storage backend selected by DB_DRIVER.
+// DB_DRIVER=memory runs the API without a database; data is lost on exit.
+func openStore() store.Store {
+	driver := db.Driver()
+	if driver == db.Memory {
+		return store.NewMemoryStore()
+	}
+	return store.NewSQLStore(db.InitDB(driver), driver)
+}
+
 func enableCORS(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, Location")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -0,0 +1,29 @@
+// Package markdown turns user-written Markdown into HTML that is safe to
+// embed in a page.
+package markdown
+
+import (
+	"bytes"
+	"html"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var (
+	renderer = goldmark.New(goldmark.WithExtensions(extension.GFM))
+	// policy allows the formatting Markdown produces and drops scripts,
+	// event handlers, styles and unsafe link targets.
+	policy = bluemonday.UGCPolicy()
+)
+
+// Render converts GitHub Flavored Markdown to sanitized HTML. Raw HTML in src
+// is not passed through.
+func Render(src string) string {
+	var buf bytes.Buffer
+	if err := renderer.Convert([]byte(src), &buf); err != nil {
+		return "<p>" + html.EscapeString(src) + "</p>"
+	}
+	return policy.Sanitize(buf.String())
+}
@@ -2,12 +2,14 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
 	"strings"
 
 	"github.com/Anwarjondev/todo-api-go/handlers"
+	"github.com/Anwarjondev/todo-api-go/store"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/joho/godotenv"
 )
@@ -28,7 +30,10 @@ func init() {
 	jwtkey = []byte(os.Getenv("JWT_KEY"))
 }
 
-func AuthMiddleware(next http.Handler) http.Handler {
+// AuthMiddleware accepts requests with a valid access token. The user's role
+// is read from users on every request rather than trusted from the token, so
+// a role change takes effect at once.
+func AuthMiddleware(users store.UserStore, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
@@ -50,8 +55,19 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			http.Error(w, "Unathorized", http.StatusUnauthorized)
 			return
 		}
-		ctx := context.WithValue(r.Context(), "user_id", int(claims.UserId))
-		ctx = context.WithValue(ctx, "role", string(claims.Role))
+		user, err := users.GetUser(r.Context(), claims.UserId)
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+			return
+		} else if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		ctx := context.WithValue(r.Context(), "user_id", user.ID)
+		ctx = context.WithValue(ctx, "role", user.Role)
+		// Changes made while serving the request are recorded in the todo
+		// history as made by this user.
+		ctx = store.WithActor(ctx, int(claims.UserId))
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
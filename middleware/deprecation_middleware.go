@@ -0,0 +1,13 @@
+package middleware
+
+import "net/http"
+
+// Deprecated marks responses from a route that is kept only for backward
+// compatibility, pointing clients at its replacement with a Link header.
+func Deprecated(successor string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+		next(w, r)
+	}
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Attachment describes a file uploaded to a todo. The file itself lives in a
+// blobs.BlobStore under BlobKey.
+type Attachment struct {
+	ID          int       `json:"id"`
+	TodoID      int       `json:"todo_id"`
+	UserID      int       `json:"user_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+	BlobKey     string    `json:"-"`
+}
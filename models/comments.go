@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Comment is a Markdown message on a todo. Body is returned as written and
+// BodyHTML as sanitized HTML rendered from it.
+type Comment struct {
+	ID        int       `json:"id"`
+	TodoID    int       `json:"todo_id"`
+	UserID    int       `json:"user_id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	BodyHTML  string    `json:"body_html"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CommentModel struct {
+	Body string `json:"body"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// The actions recorded in a todo's history.
+const (
+	HistoryCreated  = "created"
+	HistoryUpdated  = "updated"
+	HistoryDeleted  = "deleted"
+	HistoryRestored = "restored"
+)
+
+// HistoryEntry records one change to a todo: who made it and when, the
+// version the todo reached, and the fields that changed. ActorID is nil
+// when the change was made by the server itself.
+type HistoryEntry struct {
+	ID        int                    `json:"id"`
+	TodoID    int                    `json:"todo_id"`
+	ActorID   *int                   `json:"actor_id"`
+	Action    string                 `json:"action"`
+	Version   int                    `json:"version"`
+	Changes   map[string]FieldChange `json:"changes"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// FieldChange holds the JSON values of a todo field before and after a
+// change; null stands for an unset field.
+type FieldChange struct {
+	From json.RawMessage `json:"from" swaggertype:"object"`
+	To   json.RawMessage `json:"to" swaggertype:"object"`
+}
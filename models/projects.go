@@ -0,0 +1,25 @@
+package models
+
+type Project struct {
+	ID       int    `json:"id"`
+	UserID   int    `json:"user_id"`
+	Name     string `json:"name"`
+	Color    string `json:"color"`
+	Archived bool   `json:"archived"`
+	Position int    `json:"position"`
+	IsInbox  bool   `json:"is_inbox"`
+}
+
+type ProjectModel struct {
+	Name     string `json:"name"`
+	Color    string `json:"color"`
+	Position int    `json:"position"`
+}
+
+// PatchProjectModel documents the fields accepted by PATCH /projects/{id}.
+type PatchProjectModel struct {
+	Name     *string `json:"name,omitempty"`
+	Color    *string `json:"color,omitempty"`
+	Archived *bool   `json:"archived,omitempty"`
+	Position *int    `json:"position,omitempty"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Series holds what the occurrences of a recurring todo share. Each
+// occurrence is a Todo with SeriesID set; the next one is created from the
+// series when the current one is completed.
+type Series struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Title     string    `json:"title"`
+	ProjectID int       `json:"project_id"`
+	RRule     string    `json:"rrule"`
+	Timezone  string    `json:"timezone"`
+	DTStart   time.Time `json:"dtstart"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// The roles a share can grant.
+const (
+	// ShareViewer lets the grantee read the shared todos.
+	ShareViewer = "viewer"
+	// ShareEditor also lets the grantee change and delete them.
+	ShareEditor = "editor"
+)
+
+// Share grants GranteeID access to one of OwnerID's todos and its subtasks,
+// or to all of OwnerID's todos when TodoID is nil.
+type Share struct {
+	ID        int       `json:"id"`
+	OwnerID   int       `json:"owner_id"`
+	Owner     string    `json:"owner"`
+	GranteeID int       `json:"grantee_id"`
+	Grantee   string    `json:"grantee"`
+	TodoID    *int      `json:"todo_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ShareModel struct {
+	// Username is the user to share with.
+	Username string `json:"username"`
+	// TodoID is the todo to share; leave it out to share every todo.
+	TodoID *int   `json:"todo_id,omitempty"`
+	Role   string `json:"role" enums:"viewer,editor"`
+}
@@ -0,0 +1,47 @@
+package models
+
+// Status is a step in a user's workflow. Todos in a status that IsDone count
+// as completed. Transitions lists the statuses a todo may move to from this
+// one; an empty list allows all of them.
+type Status struct {
+	ID          int    `json:"id"`
+	UserID      int    `json:"user_id"`
+	Name        string `json:"name"`
+	Position    int    `json:"position"`
+	IsDone      bool   `json:"is_done"`
+	Transitions []int  `json:"transitions"`
+}
+
+// Allows reports whether a todo may move from s to the status toID.
+func (s Status) Allows(toID int) bool {
+	if len(s.Transitions) == 0 || toID == s.ID {
+		return true
+	}
+	for _, id := range s.Transitions {
+		if id == toID {
+			return true
+		}
+	}
+	return false
+}
+
+type StatusModel struct {
+	Name        string `json:"name"`
+	Position    int    `json:"position"`
+	IsDone      bool   `json:"is_done"`
+	Transitions []int  `json:"transitions,omitempty"`
+}
+
+// PatchStatusModel documents the fields accepted by PATCH /statuses/{id}.
+type PatchStatusModel struct {
+	Name        *string `json:"name,omitempty"`
+	Position    *int    `json:"position,omitempty"`
+	IsDone      *bool   `json:"is_done,omitempty"`
+	Transitions []int   `json:"transitions,omitempty"`
+}
+
+// BoardColumn is one status of a board together with the todos in it.
+type BoardColumn struct {
+	Status Status `json:"status"`
+	Todos  []Todo `json:"todos"`
+}
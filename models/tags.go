@@ -0,0 +1,11 @@
+package models
+
+type Tag struct {
+	ID     int    `json:"id"`
+	UserID int    `json:"user_id"`
+	Name   string `json:"name"`
+}
+
+type TagModel struct {
+	Name string `json:"name"`
+}
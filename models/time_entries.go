@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// TimeEntry is time a user tracked on a todo. StoppedAt is nil while the
+// entry is the user's running timer, and DurationSeconds then counts up to
+// the time of the request.
+type TimeEntry struct {
+	ID              int        `json:"id"`
+	TodoID          int        `json:"todo_id"`
+	TodoTitle       string     `json:"todo_title"`
+	UserID          int        `json:"user_id"`
+	StartedAt       time.Time  `json:"started_at"`
+	StoppedAt       *time.Time `json:"stopped_at"`
+	DurationSeconds int64      `json:"duration_seconds"`
+	Note            string     `json:"note"`
+}
+
+// TimeEntryModel is a manually entered time entry.
+type TimeEntryModel struct {
+	StartedAt time.Time `json:"started_at"`
+	StoppedAt time.Time `json:"stopped_at"`
+	Note      string    `json:"note,omitempty"`
+}
+
+// PatchTimeEntryModel documents the fields accepted by PATCH
+// /time-entries/{id}.
+type PatchTimeEntryModel struct {
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	StoppedAt *time.Time `json:"stopped_at,omitempty"`
+	Note      *string    `json:"note,omitempty"`
+}
+
+// TimerModel is the optional body of POST /todos/{id}/timer/start.
+type TimerModel struct {
+	Note string `json:"note,omitempty"`
+}
+
+// TimeReport totals the time entries started in [From, To).
+type TimeReport struct {
+	From         time.Time   `json:"from"`
+	To           time.Time   `json:"to"`
+	TotalSeconds int64       `json:"total_seconds"`
+	Todos        []TodoTime  `json:"todos"`
+	Users        []UserTime  `json:"users"`
+	Entries      []TimeEntry `json:"entries"`
+}
+
+// TodoTime is the time tracked on one todo in a TimeReport.
+type TodoTime struct {
+	TodoID       int    `json:"todo_id"`
+	TodoTitle    string `json:"todo_title"`
+	TotalSeconds int64  `json:"total_seconds"`
+}
+
+// UserTime is the time tracked by one user in a TimeReport.
+type UserTime struct {
+	UserID       int   `json:"user_id"`
+	TotalSeconds int64 `json:"total_seconds"`
+}
@@ -1,16 +1,154 @@
 package models
 
+import "time"
+
 type Todo struct {
-	ID        int    `json:"id"`
-	Title     string `json:"title"`
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	// Completed follows the IsDone flag of the todo's status.
 	Completed bool   `json:"completed"`
+	StatusID  int    `json:"status_id"`
+	Status    string `json:"status"`
 	UserId    int    `json:"user_id"`
+	// AssigneeID is the user responsible for the todo, who may differ from
+	// its owner in UserId.
+	AssigneeID *int `json:"assignee_id"`
+	ProjectID  int  `json:"project_id"`
+	ParentID   *int `json:"parent_id"`
+	// SeriesID, RRule and Timezone are set on occurrences of a recurring todo.
+	SeriesID *int   `json:"series_id"`
+	RRule    string `json:"rrule,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+	// Rank orders the owner's todos; change it with POST /todos/{id}/move.
+	Rank string `json:"rank"`
+	// Priority is one of Priorities.
+	Priority   string     `json:"priority"`
+	DueAt      *time.Time `json:"due_at"`
+	RemindAt   *time.Time `json:"remind_at"`
+	RemindedAt *time.Time `json:"reminded_at"`
+	Tags       []Tag      `json:"tags"`
+	// BlockedBy lists the todos that must be completed first; Blocked is
+	// set while any of them is open.
+	BlockedBy []int     `json:"blocked_by"`
+	Blocked   bool      `json:"blocked"`
+	CreatedAt time.Time `json:"created_at"`
+	// Version goes up with every change to the todo and is sent as its ETag.
+	Version int `json:"version"`
+	// DeletedAt is set on todos in the trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Progress is set on todos that have subtasks.
+	Progress *Progress `json:"progress,omitempty"`
+}
+
+// Priority levels of a todo, lowest first.
+const (
+	PriorityNone   = "none"
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+// Priorities lists the priority levels, lowest first.
+var Priorities = []string{PriorityNone, PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent}
+
+// Progress counts a todo's direct subtasks and how many of them are done.
+type Progress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// Subtask is a todo together with its own subtasks.
+type Subtask struct {
+	Todo
+	Subtasks []Subtask `json:"subtasks"`
+}
+
+// TodayTodo is a todo in the today view with the score it was ranked by
+// and the reasons for it, such as "high priority" or "overdue by 2 days".
+type TodayTodo struct {
+	Todo
+	Score   int      `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// TodoPage is one page of a todo listing. NextCursor is empty on the last page.
+type TodoPage struct {
+	Todos      []Todo `json:"todos"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 type TodoModel struct {
-	Title string `json:"title"`
+	Title      string     `json:"title"`
+	StatusID   int        `json:"status_id,omitempty"`
+	Priority   string     `json:"priority,omitempty"`
+	AssigneeID *int       `json:"assignee_id,omitempty"`
+	ProjectID  int        `json:"project_id,omitempty"`
+	ParentID   *int       `json:"parent_id,omitempty"`
+	DueAt      *time.Time `json:"due_at,omitempty"`
+	RemindAt   *time.Time `json:"remind_at,omitempty"`
+	// RRule makes the todo recurring, starting at DueAt. Timezone is the IANA
+	// zone the rule is evaluated in (default UTC).
+	RRule    string `json:"rrule,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
 }
 type UpdateTodoModel struct {
-	Title string `json:"title"`
-	Completed bool `json:"completed"`
-}
\ No newline at end of file
+	Title     string `json:"title"`
+	Completed bool   `json:"completed"`
+}
+
+// PatchTodoModel documents the fields accepted by PATCH /todos/{id}. Omitted
+// fields are left unchanged and null clears a field.
+type PatchTodoModel struct {
+	Title      *string    `json:"title,omitempty"`
+	Completed  *bool      `json:"completed,omitempty"`
+	StatusID   *int       `json:"status_id,omitempty"`
+	Priority   *string    `json:"priority,omitempty"`
+	AssigneeID *int       `json:"assignee_id,omitempty"`
+	ProjectID  *int       `json:"project_id,omitempty"`
+	ParentID   *int       `json:"parent_id,omitempty"`
+	DueAt      *time.Time `json:"due_at,omitempty"`
+	RemindAt   *time.Time `json:"remind_at,omitempty"`
+	RRule      *string    `json:"rrule,omitempty"`
+	Timezone   *string    `json:"timezone,omitempty"`
+}
+
+// MoveTodoModel places a todo among its owner's todos: directly after the
+// todo After, directly before the todo Before, or between the two.
+type MoveTodoModel struct {
+	After  *int `json:"after,omitempty"`
+	Before *int `json:"before,omitempty"`
+}
+
+// QuickTodoModel is a one-line description of a todo for POST /todos/quick,
+// such as "Pay rent tomorrow 9am #home !high every month". Timezone is the
+// IANA zone relative dates and times are read in (default UTC).
+type QuickTodoModel struct {
+	Text      string `json:"text"`
+	Timezone  string `json:"timezone,omitempty"`
+	ProjectID int    `json:"project_id,omitempty"`
+}
+
+// QuickTodo is a todo created from a QuickTodoModel, together with the
+// words that were recognized in the text.
+type QuickTodo struct {
+	Todo       Todo         `json:"todo"`
+	Recognized []QuickToken `json:"recognized"`
+}
+
+// QuickToken is a run of words recognized in a quick-add text. Type is date,
+// time, tag, priority or recurrence, and Value the normalized form of Text.
+type QuickToken struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Value string `json:"value"`
+}
+
+// TodoConflict is returned with 412 Precondition Failed when a change was
+// based on an outdated version of a todo. Todo is the current state, for the
+// client to merge its change into and retry with the new Version.
+type TodoConflict struct {
+	Message string `json:"message"`
+	Version int    `json:"version"`
+	Todo    Todo   `json:"todo"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type User struct {
 	ID       int    `json:"id"`
 	Username string `json:"username"`
@@ -17,3 +19,18 @@ type AllUser struct {
 	Username string `json:"username"`
 	Role     string `json:"role"`
 }
+
+// RoleChange records one change of a user's role. ChangedBy is nil when the
+// change was made by the server itself, e.g. when bootstrapping the first admin.
+type RoleChange struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	OldRole   string    `json:"old_role"`
+	NewRole   string    `json:"new_role"`
+	ChangedBy *int      `json:"changed_by"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type RoleModel struct {
+	Role string `json:"role"`
+}
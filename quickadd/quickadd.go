@@ -0,0 +1,577 @@
+// Package quickadd parses one-line todo descriptions such as
+// "Pay rent tomorrow 9am #home !high every month" into a title, a due date,
+// tags, a priority and a recurrence rule.
+//
+// Words are recognized as follows; everything else is kept as the title.
+//
+//   - Tags: #name. A todo can have any number of tags.
+//   - Priority: !low, !medium (or !med), !high or !urgent.
+//   - Dates: today, tomorrow, a weekday (optionally after "next"), next week
+//     (the coming Monday), next month (its first day), "in 3 days" (also
+//     weeks, months and years), a month and day such as "nov 3" or "3 nov",
+//     or an ISO date such as 2026-11-03. A leading "on" is part of the date.
+//   - Times: 9am, 9:30pm, 9 am, 21:00, noon or midnight. A leading "at" is
+//     part of the time. "in 2 hours" (also minutes) sets both date and time.
+//   - Recurrence: daily, weekly, monthly, yearly, "every day" (also week,
+//     month and year), "every 2 weeks", "every other month", "every weekday"
+//     and "every monday and thursday".
+//
+// Only the first date, time, priority and recurrence are recognized; later
+// ones are left in the title. Relative dates are resolved against the time
+// and time zone of the now passed to Parse; a time skipped by a daylight
+// saving change moves to the end of the gap.
+package quickadd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoTitle is returned by Parse when nothing is left for the title once the
+// recognized words are removed.
+var ErrNoTitle = errors.New("quickadd: the text has no title")
+
+// ErrTooFar is returned by Parse when "in N units" or "every N units" reaches
+// more than MaxYears ahead.
+var ErrTooFar = fmt.Errorf("quickadd: dates and recurrences can reach at most %d years ahead", MaxYears)
+
+// MaxYears bounds the quantities in "in N units" and "every N units".
+const MaxYears = 100
+
+// maxCounts holds the largest quantity of each unit within MaxYears.
+var maxCounts = map[string]int{
+	"minute": MaxYears * 366 * 24 * 60,
+	"hour":   MaxYears * 366 * 24,
+	"day":    MaxYears * 366,
+	"week":   MaxYears * 53,
+	"month":  MaxYears * 12,
+	"year":   MaxYears,
+}
+
+// Kinds of recognized words.
+const (
+	KindDate       = "date"
+	KindTime       = "time"
+	KindTag        = "tag"
+	KindPriority   = "priority"
+	KindRecurrence = "recurrence"
+)
+
+// Token is a run of words Parse recognized. Value is its normalized form: a
+// date as YYYY-MM-DD ("YYYY-MM-DD HH:MM" for "in 2 hours"), a time as HH:MM,
+// a tag name without the #, a priority level or an RRULE.
+type Token struct {
+	Kind  string
+	Text  string
+	Value string
+}
+
+// Result is a parsed todo description.
+type Result struct {
+	Title string
+	// DueAt is nil when the text names neither a date, a time nor a
+	// recurrence. A date without a time is due at the end of that day, and a
+	// time without a date is due at its next occurrence.
+	DueAt    *time.Time
+	Tags     []string
+	Priority string
+	// RRule is an iCalendar RRULE value such as "FREQ=WEEKLY;BYDAY=MO,TH".
+	// The first occurrence is DueAt.
+	RRule      string
+	Recognized []Token
+}
+
+// Priorities maps the words accepted after ! to priority levels.
+var Priorities = map[string]string{
+	"low":    "low",
+	"medium": "medium",
+	"med":    "medium",
+	"high":   "high",
+	"urgent": "urgent",
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+var months = map[string]time.Month{
+	"january": time.January, "jan": time.January,
+	"february": time.February, "feb": time.February,
+	"march": time.March, "mar": time.March,
+	"april": time.April, "apr": time.April,
+	"may":  time.May,
+	"june": time.June, "jun": time.June,
+	"july": time.July, "jul": time.July,
+	"august": time.August, "aug": time.August,
+	"september": time.September, "sep": time.September, "sept": time.September,
+	"october": time.October, "oct": time.October,
+	"november": time.November, "nov": time.November,
+	"december": time.December, "dec": time.December,
+}
+
+// frequencies maps singular and plural units to RRULE frequencies.
+var frequencies = map[string]string{
+	"day": "DAILY", "days": "DAILY",
+	"week": "WEEKLY", "weeks": "WEEKLY",
+	"month": "MONTHLY", "months": "MONTHLY",
+	"year": "YEARLY", "years": "YEARLY",
+}
+
+var byDay = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+var (
+	clock12 = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)$`)
+	clock24 = regexp.MustCompile(`^(\d{1,2}):(\d{2})$`)
+	isoDate = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+)
+
+// parser holds the state of one Parse call.
+type parser struct {
+	now   time.Time
+	words []string
+	// lower holds the words in lower case without trailing punctuation.
+	lower []string
+
+	onDate  *time.Time
+	atTime  *time.Duration
+	instant *time.Time
+	days    []time.Weekday
+	result  Result
+	// err is set when a recognized quantity is out of range.
+	err error
+}
+
+// Parse parses text relative to now, whose location is the time zone dates
+// and times are read in.
+func Parse(text string, now time.Time) (Result, error) {
+	p := &parser{now: now, words: strings.Fields(text)}
+	for _, w := range p.words {
+		p.lower = append(p.lower, strings.TrimRight(strings.ToLower(w), ",.;"))
+	}
+	var title []string
+	for i := 0; i < len(p.words); {
+		n := p.match(i)
+		if n == 0 {
+			title = append(title, p.words[i])
+			n = 1
+		}
+		i += n
+	}
+	if p.err != nil {
+		return Result{}, p.err
+	}
+	p.result.Title = strings.Join(title, " ")
+	if p.result.Title == "" {
+		return Result{}, ErrNoTitle
+	}
+	p.result.DueAt = p.due()
+	return p.result, nil
+}
+
+// match recognizes the words starting at i, returning how many it consumed
+// or 0 when they are part of the title.
+func (p *parser) match(i int) int {
+	matchers := []func(int) (int, Token){p.tag, p.priority, p.recurrence, p.relative, p.date, p.clock}
+	for _, m := range matchers {
+		if n, tok := m(i); n > 0 {
+			tok.Text = strings.Join(p.words[i:i+n], " ")
+			p.result.Recognized = append(p.result.Recognized, tok)
+			return n
+		}
+	}
+	return 0
+}
+
+func (p *parser) word(i int) string {
+	if i < len(p.lower) {
+		return p.lower[i]
+	}
+	return ""
+}
+
+func (p *parser) tag(i int) (int, Token) {
+	word := strings.TrimRight(p.words[i], ",.;")
+	name := strings.TrimPrefix(word, "#")
+	if name == word || name == "" || strings.HasPrefix(name, "#") {
+		return 0, Token{}
+	}
+	p.result.Tags = append(p.result.Tags, name)
+	return 1, Token{Kind: KindTag, Value: name}
+}
+
+func (p *parser) priority(i int) (int, Token) {
+	word := p.word(i)
+	level, ok := Priorities[strings.TrimPrefix(word, "!")]
+	if !strings.HasPrefix(word, "!") || !ok || p.result.Priority != "" {
+		return 0, Token{}
+	}
+	p.result.Priority = level
+	return 1, Token{Kind: KindPriority, Value: level}
+}
+
+func (p *parser) recurrence(i int) (int, Token) {
+	if p.result.RRule != "" {
+		return 0, Token{}
+	}
+	n, freq, interval := 0, "", 1
+	switch p.word(i) {
+	case "daily":
+		n, freq = 1, "DAILY"
+	case "weekly":
+		n, freq = 1, "WEEKLY"
+	case "monthly":
+		n, freq = 1, "MONTHLY"
+	case "yearly", "annually":
+		n, freq = 1, "YEARLY"
+	case "every":
+		n, freq, interval = p.every(i + 1)
+		if n == 0 {
+			return 0, Token{}
+		}
+		n++
+	default:
+		return 0, Token{}
+	}
+	rule := "FREQ=" + freq
+	if interval > 1 {
+		rule += ";INTERVAL=" + strconv.Itoa(interval)
+	}
+	if len(p.days) > 0 {
+		var days []string
+		for _, d := range p.days {
+			days = append(days, byDay[d])
+		}
+		rule += ";BYDAY=" + strings.Join(days, ",")
+	}
+	p.result.RRule = rule
+	return n, Token{Kind: KindRecurrence, Value: rule}
+}
+
+// every parses what follows "every", returning the words consumed, the
+// frequency and the interval.
+func (p *parser) every(i int) (int, string, int) {
+	word := p.word(i)
+	if freq, ok := frequencies[word]; ok && !strings.HasSuffix(word, "s") {
+		return 1, freq, 1
+	}
+	if word == "weekday" || word == "weekdays" {
+		p.days = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+		return 1, "WEEKLY", 1
+	}
+	if word == "other" {
+		if freq, ok := frequencies[p.word(i+1)]; ok {
+			return 2, freq, 2
+		}
+		return 0, "", 0
+	}
+	if interval, ok := count(word); ok {
+		if freq, ok := frequencies[p.word(i+1)]; ok {
+			p.checkCount(interval, p.word(i+1))
+			return 2, freq, interval
+		}
+		return 0, "", 0
+	}
+	// A list of weekdays: "monday", "mon, thu" or "monday and thursday".
+	n := 0
+	var days []time.Weekday
+	for {
+		day, ok := weekday(p.word(i + n))
+		if !ok {
+			break
+		}
+		days = append(days, day)
+		n++
+		if p.word(i+n) == "and" {
+			if _, ok := weekday(p.word(i + n + 1)); ok {
+				n++
+			}
+		}
+	}
+	if n == 0 {
+		return 0, "", 0
+	}
+	p.days = days
+	return n, "WEEKLY", 1
+}
+
+// relative parses "in N units".
+func (p *parser) relative(i int) (int, Token) {
+	if p.word(i) != "in" || p.onDate != nil || p.instant != nil {
+		return 0, Token{}
+	}
+	n, ok := count(p.word(i + 1))
+	if !ok {
+		if p.word(i+1) != "a" && p.word(i+1) != "an" {
+			return 0, Token{}
+		}
+		n = 1
+	}
+	unit := strings.TrimSuffix(p.word(i+2), "s")
+	if _, ok := maxCounts[unit]; ok && !p.checkCount(n, unit) {
+		return 3, Token{}
+	}
+	if unit == "minute" || unit == "hour" {
+		if p.atTime != nil {
+			return 0, Token{}
+		}
+		d := time.Duration(n) * time.Minute
+		if unit == "hour" {
+			d = time.Duration(n) * time.Hour
+		}
+		at := p.now.Add(d).Truncate(time.Minute)
+		p.instant = &at
+		return 3, Token{Kind: KindDate, Value: at.Format("2006-01-02 15:04")}
+	}
+	today := p.today()
+	var date time.Time
+	switch unit {
+	case "day":
+		date = today.AddDate(0, 0, n)
+	case "week":
+		date = today.AddDate(0, 0, 7*n)
+	case "month":
+		date = today.AddDate(0, n, 0)
+	case "year":
+		date = today.AddDate(n, 0, 0)
+	default:
+		return 0, Token{}
+	}
+	p.onDate = &date
+	return 3, Token{Kind: KindDate, Value: date.Format("2006-01-02")}
+}
+
+// checkCount reports whether n units stay within MaxYears, setting p.err
+// when they do not.
+func (p *parser) checkCount(n int, unit string) bool {
+	if n > maxCounts[strings.TrimSuffix(unit, "s")] {
+		p.err = ErrTooFar
+		return false
+	}
+	return true
+}
+
+// date parses a calendar date, optionally after "on".
+func (p *parser) date(i int) (int, Token) {
+	if p.onDate != nil || p.instant != nil {
+		return 0, Token{}
+	}
+	skip := 0
+	if p.word(i) == "on" {
+		skip = 1
+	}
+	n, date, ok := p.calendarDate(i + skip)
+	if !ok {
+		return 0, Token{}
+	}
+	p.onDate = &date
+	return skip + n, Token{Kind: KindDate, Value: date.Format("2006-01-02")}
+}
+
+func (p *parser) calendarDate(i int) (int, time.Time, bool) {
+	today := p.today()
+	word := p.word(i)
+	switch word {
+	case "today":
+		return 1, today, true
+	case "tomorrow":
+		return 1, today.AddDate(0, 0, 1), true
+	case "next":
+		switch p.word(i + 1) {
+		case "week":
+			return 2, nextWeekday(today, time.Monday), true
+		case "month":
+			return 2, time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, today.Location()), true
+		}
+		if day, ok := weekdays[p.word(i+1)]; ok {
+			return 2, nextWeekday(today, day), true
+		}
+		return 0, time.Time{}, false
+	}
+	if day, ok := weekdays[word]; ok {
+		return 1, nextWeekday(today, day), true
+	}
+	if isoDate.MatchString(word) {
+		date, err := time.ParseInLocation("2006-01-02", word, today.Location())
+		return 1, date, err == nil
+	}
+	// "nov 3" or "3 nov".
+	if month, ok := months[word]; ok {
+		if day, err := strconv.Atoi(p.word(i + 1)); err == nil {
+			date, ok := p.monthDay(month, day)
+			return 2, date, ok
+		}
+	}
+	if day, err := strconv.Atoi(word); err == nil {
+		if month, ok := months[p.word(i+1)]; ok {
+			date, ok := p.monthDay(month, day)
+			return 2, date, ok
+		}
+	}
+	return 0, time.Time{}, false
+}
+
+// monthDay returns the next day/month on or after today.
+func (p *parser) monthDay(month time.Month, day int) (time.Time, bool) {
+	today := p.today()
+	date := time.Date(today.Year(), month, day, 0, 0, 0, 0, today.Location())
+	if date.Month() != month {
+		return time.Time{}, false
+	}
+	if date.Before(today) {
+		date = date.AddDate(1, 0, 0)
+	}
+	return date, true
+}
+
+// clock parses a time of day, optionally after "at".
+func (p *parser) clock(i int) (int, Token) {
+	if p.atTime != nil || p.instant != nil {
+		return 0, Token{}
+	}
+	skip := 0
+	if p.word(i) == "at" {
+		skip = 1
+	}
+	n, offset, ok := p.timeOfDay(i + skip)
+	if !ok {
+		return 0, Token{}
+	}
+	p.atTime = &offset
+	return skip + n, Token{Kind: KindTime, Value: fmt.Sprintf("%02d:%02d", int(offset.Hours()), int(offset.Minutes())%60)}
+}
+
+func (p *parser) timeOfDay(i int) (int, time.Duration, bool) {
+	word := p.word(i)
+	switch word {
+	case "noon":
+		return 1, 12 * time.Hour, true
+	case "midnight":
+		return 1, 0, true
+	}
+	n := 1
+	if next := p.word(i + 1); (next == "am" || next == "pm") && !strings.ContainsAny(word, "apm") {
+		word += next
+		n = 2
+	}
+	if m := clock12.FindStringSubmatch(word); m != nil {
+		hour, _ := strconv.Atoi(m[1])
+		minute, _ := strconv.Atoi(m[2])
+		if hour < 1 || hour > 12 || minute > 59 {
+			return 0, 0, false
+		}
+		hour %= 12
+		if m[3] == "pm" {
+			hour += 12
+		}
+		return n, time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute, true
+	}
+	if m := clock24.FindStringSubmatch(word); m != nil {
+		hour, _ := strconv.Atoi(m[1])
+		minute, _ := strconv.Atoi(m[2])
+		if hour > 23 || minute > 59 {
+			return 0, 0, false
+		}
+		return 1, time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute, true
+	}
+	return 0, 0, false
+}
+
+// due combines the recognized date, time and recurrence into the due date.
+func (p *parser) due() *time.Time {
+	if p.instant != nil {
+		return p.instant
+	}
+	if p.onDate == nil && p.atTime == nil && p.result.RRule == "" {
+		return nil
+	}
+	today := p.today()
+	if p.onDate != nil {
+		due := at(*p.onDate, p.atTime)
+		return &due
+	}
+	// Without a date the todo is due at the first matching time from now
+	// on, on one of the recurrence's weekdays if it has any.
+	for day := today; ; day = day.AddDate(0, 0, 1) {
+		if len(p.days) > 0 && !hasWeekday(p.days, day.Weekday()) {
+			continue
+		}
+		if due := at(day, p.atTime); due.After(p.now) {
+			return &due
+		}
+	}
+}
+
+func (p *parser) today() time.Time {
+	return time.Date(p.now.Year(), p.now.Month(), p.now.Day(), 0, 0, 0, 0, p.now.Location())
+}
+
+// at returns day at the time of day clock, or at the end of the day when
+// clock is nil. A time skipped when the clocks go forward is moved to the
+// first instant after the gap.
+func at(day time.Time, clock *time.Duration) time.Time {
+	hour, minute := 23, 59
+	if clock != nil {
+		hour, minute = int(clock.Hours()), int(clock.Minutes())%60
+	}
+	t := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location())
+	if t.Hour() == hour && t.Minute() == minute {
+		return t
+	}
+	// time.Date read the missing wall time with the offset on one side of the
+	// gap; the gap ends where the zone t falls in ends or starts.
+	start, end := t.ZoneBounds()
+	if wall(t).Before(time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, time.UTC)) {
+		return end
+	}
+	return start
+}
+
+// wall returns the wall clock time of t as if it were in UTC.
+func wall(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// count parses a positive quantity. Numbers too large for an int count as
+// the largest int, so that they are refused as too far rather than kept in
+// the title.
+func count(word string) (int, bool) {
+	n, err := strconv.Atoi(word)
+	if errors.Is(err, strconv.ErrRange) && !strings.HasPrefix(word, "-") {
+		return n, true
+	}
+	return n, err == nil && n > 0
+}
+
+// weekday looks up a weekday name, which may be plural as in "every mondays".
+func weekday(word string) (time.Weekday, bool) {
+	if day, ok := weekdays[word]; ok {
+		return day, true
+	}
+	day, ok := weekdays[strings.TrimSuffix(word, "s")]
+	return day, ok
+}
+
+// nextWeekday returns the first day after today that falls on weekday.
+func nextWeekday(today time.Time, weekday time.Weekday) time.Time {
+	days := (int(weekday)-int(today.Weekday())+6)%7 + 1
+	return today.AddDate(0, 0, days)
+}
+
+func hasWeekday(days []time.Weekday, day time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package quickadd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// testNow is Friday 6 March 2026, 15:04 in New York, two days before clocks
+// go forward for daylight saving time.
+func testNow(t *testing.T) time.Time {
+	t.Helper()
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return time.Date(2026, time.March, 6, 15, 4, 0, 0, loc)
+}
+
+func TestParse(t *testing.T) {
+	now := testNow(t)
+	tests := []struct {
+		name     string
+		text     string
+		title    string
+		due      string // "2006-01-02 15:04" in now's zone, or "" for none
+		tags     []string
+		priority string
+		rrule    string
+	}{
+		{name: "headline", text: "Pay rent tomorrow 9am #home !high every month",
+			title: "Pay rent", due: "2026-03-07 09:00", tags: []string{"home"}, priority: "high", rrule: "FREQ=MONTHLY"},
+		{name: "plain", text: "Buy milk", title: "Buy milk"},
+
+		// Dates without a time are due at the end of the day.
+		{name: "today", text: "Call mom today", title: "Call mom", due: "2026-03-06 23:59"},
+		{name: "tomorrow", text: "Call mom tomorrow", title: "Call mom", due: "2026-03-07 23:59"},
+		{name: "weekday", text: "Call mom monday", title: "Call mom", due: "2026-03-09 23:59"},
+		{name: "next weekday", text: "Call mom next monday", title: "Call mom", due: "2026-03-09 23:59"},
+		{name: "weekday abbreviation", text: "Call mom thurs", title: "Call mom", due: "2026-03-12 23:59"},
+		{name: "same weekday as today", text: "Call mom friday", title: "Call mom", due: "2026-03-13 23:59"},
+		{name: "next week", text: "Plan next week", title: "Plan", due: "2026-03-09 23:59"},
+		{name: "next month", text: "Plan next month", title: "Plan", due: "2026-04-01 23:59"},
+		{name: "in days", text: "Plan in 3 days", title: "Plan", due: "2026-03-09 23:59"},
+		{name: "in weeks", text: "Plan in 2 weeks", title: "Plan", due: "2026-03-20 23:59"},
+		{name: "in a month", text: "Plan in a month", title: "Plan", due: "2026-04-06 23:59"},
+		{name: "in years", text: "Plan in 2 years", title: "Plan", due: "2028-03-06 23:59"},
+		{name: "in most years", text: "Plan in 100 years", title: "Plan", due: "2126-03-06 23:59"},
+		{name: "month day", text: "Vote nov 3", title: "Vote", due: "2026-11-03 23:59"},
+		{name: "day month", text: "Vote 3 November", title: "Vote", due: "2026-11-03 23:59"},
+		{name: "month day today", text: "Vote mar 6", title: "Vote", due: "2026-03-06 23:59"},
+		{name: "month day rolls into next year", text: "Vote feb 2", title: "Vote", due: "2027-02-02 23:59"},
+		{name: "invalid month day", text: "Vote feb 30", title: "Vote feb 30"},
+		{name: "iso date", text: "Vote 2026-11-03", title: "Vote", due: "2026-11-03 23:59"},
+		{name: "on prefix", text: "Meet on friday", title: "Meet", due: "2026-03-13 23:59"},
+		{name: "on without date", text: "Turn on lights", title: "Turn on lights"},
+
+		// Times without a date are due at their next occurrence.
+		{name: "time later today", text: "Meet 5pm", title: "Meet", due: "2026-03-06 17:00"},
+		{name: "time passed today", text: "Meet 9am", title: "Meet", due: "2026-03-07 09:00"},
+		{name: "time with minutes", text: "Meet tomorrow 9:30pm", title: "Meet", due: "2026-03-07 21:30"},
+		{name: "time in two words", text: "Meet tomorrow 9 am", title: "Meet", due: "2026-03-07 09:00"},
+		{name: "24-hour time", text: "Meet tomorrow 21:00", title: "Meet", due: "2026-03-07 21:00"},
+		{name: "at prefix", text: "Meet tomorrow at 10am", title: "Meet", due: "2026-03-07 10:00"},
+		{name: "at without time", text: "Look at it", title: "Look at it"},
+		{name: "noon", text: "Lunch noon", title: "Lunch", due: "2026-03-07 12:00"},
+		{name: "midnight", text: "Deploy midnight", title: "Deploy", due: "2026-03-07 00:00"},
+		{name: "midnight starts the named day", text: "Deploy sunday midnight", title: "Deploy", due: "2026-03-08 00:00"},
+		{name: "invalid time", text: "Meet 13pm", title: "Meet 13pm"},
+		{name: "in minutes", text: "Stretch in 90 minutes", title: "Stretch", due: "2026-03-06 16:34"},
+		{name: "in an hour", text: "Stretch in an hour", title: "Stretch", due: "2026-03-06 16:04"},
+		// 48 hours later is 16:04, not 15:04, once the clocks have gone forward.
+		{name: "in hours across dst", text: "Stretch in 48 hours", title: "Stretch", due: "2026-03-08 16:04"},
+		{name: "day across dst", text: "Stretch sunday 9am", title: "Stretch", due: "2026-03-08 09:00"},
+		// 2:30 does not exist that day; the clocks go from 2:00 straight to 3:00.
+		{name: "time skipped by dst", text: "Stretch sunday 2:30am", title: "Stretch", due: "2026-03-08 03:00"},
+
+		// Recurrences start at their first occurrence from now on.
+		{name: "daily", text: "Stretch daily", title: "Stretch", due: "2026-03-06 23:59", rrule: "FREQ=DAILY"},
+		{name: "weekly", text: "Review weekly", title: "Review", due: "2026-03-06 23:59", rrule: "FREQ=WEEKLY"},
+		{name: "monthly", text: "Budget monthly", title: "Budget", due: "2026-03-06 23:59", rrule: "FREQ=MONTHLY"},
+		{name: "yearly", text: "Taxes yearly", title: "Taxes", due: "2026-03-06 23:59", rrule: "FREQ=YEARLY"},
+		{name: "every day", text: "Stretch every day 7am", title: "Stretch", due: "2026-03-07 07:00", rrule: "FREQ=DAILY"},
+		{name: "every n weeks", text: "Mow every 2 weeks", title: "Mow", due: "2026-03-06 23:59", rrule: "FREQ=WEEKLY;INTERVAL=2"},
+		{name: "every other month", text: "Haircut every other month", title: "Haircut", due: "2026-03-06 23:59", rrule: "FREQ=MONTHLY;INTERVAL=2"},
+		{name: "every weekday", text: "Standup every weekday 9am", title: "Standup", due: "2026-03-09 09:00", rrule: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"},
+		{name: "every weekdays", text: "Gym every monday and thursday 6pm", title: "Gym", due: "2026-03-09 18:00", rrule: "FREQ=WEEKLY;BYDAY=MO,TH"},
+		{name: "every plural weekday", text: "Gym every tuesdays", title: "Gym", due: "2026-03-10 23:59", rrule: "FREQ=WEEKLY;BYDAY=TU"},
+		{name: "recurrence with date", text: "Rent every month nov 1", title: "Rent", due: "2026-11-01 23:59", rrule: "FREQ=MONTHLY"},
+		{name: "every without unit", text: "Read every book", title: "Read every book"},
+
+		// Only the first of each is recognized.
+		{name: "second date", text: "Call tomorrow friday", title: "Call friday", due: "2026-03-07 23:59"},
+		{name: "second time", text: "Call 5pm 6pm", title: "Call 6pm", due: "2026-03-06 17:00"},
+		{name: "second priority", text: "Call !high !low", title: "Call !low", priority: "high"},
+		{name: "second recurrence", text: "Call daily weekly", title: "Call weekly", due: "2026-03-06 23:59", rrule: "FREQ=DAILY"},
+		{name: "date after in", text: "Call in 2 hours tomorrow", title: "Call tomorrow", due: "2026-03-06 17:04"},
+
+		{name: "tags and priority", text: "Fix bug #work #urgent-ish !med", title: "Fix bug", tags: []string{"work", "urgent-ish"}, priority: "medium"},
+		{name: "unknown priority", text: "Fix bug !extreme", title: "Fix bug !extreme"},
+		{name: "punctuation", text: "Call mom tomorrow, #family.", title: "Call mom", due: "2026-03-07 23:59", tags: []string{"family"}},
+		{name: "case", text: "Call Mom TOMORROW 9AM", title: "Call Mom", due: "2026-03-07 09:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.text, now)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.text, err)
+			}
+			if got.Title != tt.title {
+				t.Errorf("Title = %q, want %q", got.Title, tt.title)
+			}
+			if tt.due == "" {
+				if got.DueAt != nil {
+					t.Errorf("DueAt = %v, want none", got.DueAt)
+				}
+			} else {
+				want, err := time.ParseInLocation("2006-01-02 15:04", tt.due, now.Location())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.DueAt == nil || !got.DueAt.Equal(want) {
+					t.Errorf("DueAt = %v, want %v", got.DueAt, want)
+				}
+			}
+			if !reflect.DeepEqual(got.Tags, tt.tags) {
+				t.Errorf("Tags = %q, want %q", got.Tags, tt.tags)
+			}
+			if got.Priority != tt.priority {
+				t.Errorf("Priority = %q, want %q", got.Priority, tt.priority)
+			}
+			if got.RRule != tt.rrule {
+				t.Errorf("RRule = %q, want %q", got.RRule, tt.rrule)
+			}
+		})
+	}
+}
+
+func TestParseRecognized(t *testing.T) {
+	got, err := Parse("Pay rent tomorrow at 9am #home !high every month", testNow(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Token{
+		{Kind: KindDate, Text: "tomorrow", Value: "2026-03-07"},
+		{Kind: KindTime, Text: "at 9am", Value: "09:00"},
+		{Kind: KindTag, Text: "#home", Value: "home"},
+		{Kind: KindPriority, Text: "!high", Value: "high"},
+		{Kind: KindRecurrence, Text: "every month", Value: "FREQ=MONTHLY"},
+	}
+	if !reflect.DeepEqual(got.Recognized, want) {
+		t.Errorf("Recognized = %+v, want %+v", got.Recognized, want)
+	}
+}
+
+func TestParseNoTitle(t *testing.T) {
+	for _, text := range []string{"", "   ", "tomorrow 9am #home !high", "every monday"} {
+		if _, err := Parse(text, testNow(t)); !errors.Is(err, ErrNoTitle) {
+			t.Errorf("Parse(%q) error = %v, want ErrNoTitle", text, err)
+		}
+	}
+}
+
+func TestParseTooFar(t *testing.T) {
+	for _, text := range []string{
+		"Plan in 101 years",
+		"Plan in 1201 months",
+		"Plan in 99999999999999999999 days",
+		"Stretch in 60000000 minutes",
+		"Mow every 5301 weeks",
+		"Mow every 99999999999999999999 days",
+	} {
+		if _, err := Parse(text, testNow(t)); !errors.Is(err, ErrTooFar) {
+			t.Errorf("Parse(%q) error = %v, want ErrTooFar", text, err)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// Package rank generates lexicographic rank keys, which order items by plain
+// string comparison. A key can always be placed between two others, so
+// moving one item never requires renumbering its neighbours. Keys grow as
+// items are repeatedly moved into the same gap; Spread returns short, evenly
+// spaced keys to rebalance them. Next appends items without growing keys.
+package rank
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+// Digits are the characters rank keys are made of, in ascending order.
+const Digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+const base = len(Digits)
+
+var (
+	// ErrNoRoom is returned by Between when a does not come before b.
+	ErrNoRoom = errors.New("rank: no key fits between the given keys")
+	// ErrInvalidKey is returned by Between for keys it did not generate.
+	ErrInvalidKey = errors.New("rank: invalid key")
+)
+
+// Between returns a key that sorts after a and before b. An empty a means
+// before every other key and an empty b after every other key.
+func Between(a, b string) (string, error) {
+	if (a != "" && !valid(a)) || (b != "" && !valid(b)) {
+		return "", ErrInvalidKey
+	}
+	if b != "" && a >= b {
+		return "", ErrNoRoom
+	}
+	return midpoint(a, b), nil
+}
+
+// After returns a key that sorts after a, or the first key when a is empty.
+func After(a string) (string, error) {
+	return Between(a, "")
+}
+
+// appendWidth is the number of digits Next counts in. Four digits leave room
+// for hundreds of thousands of appends after a key in the middle of the range.
+const appendWidth = 4
+
+// Next returns a key that sorts after a, or the first key when a is empty,
+// for adding an item after every other. After halves the space left above a,
+// so repeated appends make keys a digit longer every few times; Next adds
+// one to a counted in appendWidth digits, or more if a is longer, so keys
+// stay short until that space runs out.
+func Next(a string) (string, error) {
+	if a == "" {
+		return After(a)
+	}
+	if !valid(a) {
+		return "", ErrInvalidKey
+	}
+	digits := []byte(a + strings.Repeat(Digits[:1], max(appendWidth-len(a), 0)))
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := strings.IndexByte(Digits, digits[i])
+		if d < base-1 {
+			digits[i] = Digits[d+1]
+			// Trailing zeros left by the carry add nothing to the order.
+			return strings.TrimRight(string(digits), Digits[:1]), nil
+		}
+		digits[i] = Digits[0]
+	}
+	// Every digit is the last one; only a longer key sorts after a.
+	return After(a)
+}
+
+// midpoint returns a key between a and b, which are valid keys (or empty)
+// with a < b.
+func midpoint(a, b string) string {
+	if b != "" {
+		// Keep the prefix the two share; a is padded with zeros.
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			return b[:n] + midpoint(a[min(n, len(a)):], b[n:])
+		}
+	}
+	da := strings.IndexByte(Digits, digitAt(a, 0))
+	db := base
+	if b != "" {
+		db = strings.IndexByte(Digits, b[0])
+	}
+	if db-da > 1 {
+		return string(Digits[(da+db)/2])
+	}
+	// The first digits are adjacent. If b has more digits, its first digit
+	// alone sorts between them; otherwise extend a.
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(Digits[da]) + midpoint(rest, "")
+}
+
+// digitAt returns the i-th digit of key, treating missing digits as zero.
+func digitAt(key string, i int) byte {
+	if i < len(key) {
+		return key[i]
+	}
+	return Digits[0]
+}
+
+// valid reports whether key consists of digits and does not end in a zero,
+// which would leave no room for a key just below it.
+func valid(key string) bool {
+	if key == "" || key[len(key)-1] == Digits[0] {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(Digits, key[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Spread returns n evenly spaced keys in ascending order, as short as n
+// allows.
+func Spread(n int) []string {
+	width, space := 1, base
+	for space <= 2*n {
+		width++
+		space *= base
+	}
+	step := space / (n + 1)
+	keys := make([]string, n)
+	for i := range keys {
+		v := step * (i + 1)
+		digits := make([]byte, width)
+		for j := width - 1; j >= 0; j-- {
+			digits[j] = Digits[v%base]
+			v /= base
+		}
+		keys[i] = strings.TrimRight(string(digits), Digits[:1])
+	}
+	return keys
+}
+
+// Store is the storage the Rebalancer works on.
+type Store interface {
+	// UnbalancedRanks returns the users with a todo whose rank is empty or
+	// longer than maxLength.
+	UnbalancedRanks(ctx context.Context, maxLength int) ([]int, error)
+	// RebalanceRanks gives userID's todos evenly spaced keys from Spread,
+	// keeping their order.
+	RebalanceRanks(ctx context.Context, userID int) error
+}
+
+// Rebalancer periodically gives fresh keys to the todos of users whose keys
+// grew too long.
+type Rebalancer struct {
+	Store Store
+	// Interval is how often the store is checked for long keys.
+	Interval time.Duration
+	// MaxLength is the longest key left alone.
+	MaxLength int
+}
+
+func NewRebalancer(s Store) *Rebalancer {
+	return &Rebalancer{Store: s, Interval: time.Hour, MaxLength: 12}
+}
+
+// Run rebalances keys until ctx is cancelled.
+func (r *Rebalancer) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	for {
+		if err := r.Rebalance(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Rank rebalancing failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Rebalance rebalances the keys of every user with a key that is missing or
+// longer than MaxLength.
+func (r *Rebalancer) Rebalance(ctx context.Context) error {
+	users, err := r.Store.UnbalancedRanks(ctx, r.MaxLength)
+	if err != nil {
+		return err
+	}
+	for _, userID := range users {
+		if err := r.Store.RebalanceRanks(ctx, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package rank
+
+import "testing"
+
+func TestNext(t *testing.T) {
+	tests := []struct{ key, want string }{
+		{"", "i"},
+		{"i", "i001"},
+		{"i001", "i002"},
+		{"i00z", "i01"},
+		{"izzz", "j"},
+		{"xc", "xc01"},
+		{"abcde", "abcdf"},
+		{"zzzz", "zzzzi"},
+	}
+	for _, tt := range tests {
+		got, err := Next(tt.key)
+		if err != nil || got != tt.want {
+			t.Errorf("Next(%q) = %q, %v; want %q", tt.key, got, err, tt.want)
+		}
+	}
+	if _, err := Next("i0"); err != ErrInvalidKey {
+		t.Errorf("Next(%q) error = %v, want ErrInvalidKey", "i0", err)
+	}
+}
+
+func TestNextKeepsKeysShort(t *testing.T) {
+	// Appending after a rebalance starts from the last key Spread handed out.
+	for _, start := range []string{"", "i", Spread(1000)[999]} {
+		key := start
+		for i := 0; i < 1000; i++ {
+			next, err := Next(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if next <= key || !valid(next) {
+				t.Fatalf("Next(%q) = %q, want a valid key after it", key, next)
+			}
+			key = next
+		}
+		if len(key) > appendWidth {
+			t.Errorf("after 1000 appends from %q the key is %q, longer than %d digits", start, key, appendWidth)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Package recurrence computes the occurrences of recurring todos from
+// iCalendar RRULE values (RFC 5545, section 3.3.10).
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/teambition/rrule-go"
+)
+
+// Rule is an RRULE anchored at its first occurrence.
+type Rule struct {
+	rule *rrule.RRule
+}
+
+// Parse parses an RRULE value such as "FREQ=WEEKLY;BYDAY=MO,TH", with or
+// without the "RRULE:" prefix. The rule starts at dtstart and is evaluated
+// as wall-clock time in the IANA time zone tz, so a todo due at 09:00 stays
+// due at 09:00 local time across daylight saving changes. An empty tz means
+// UTC. Only DAILY, WEEKLY, MONTHLY and YEARLY rules are accepted, and
+// DTSTART cannot be part of value since dtstart takes its place.
+func Parse(value, tz string, dtstart time.Time) (*Rule, error) {
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", tz)
+	}
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if strings.Contains(value, "DTSTART") {
+		return nil, errors.New("rrule cannot contain DTSTART; the due date is used instead")
+	}
+	opt, err := rrule.StrToROptionInLocation(value, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rrule: %v", err)
+	}
+	switch opt.Freq {
+	case rrule.DAILY, rrule.WEEKLY, rrule.MONTHLY, rrule.YEARLY:
+	default:
+		return nil, errors.New("rrule FREQ must be DAILY, WEEKLY, MONTHLY or YEARLY")
+	}
+	opt.Dtstart = dtstart.In(loc)
+	r, err := rrule.NewRRule(*opt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rrule: %v", err)
+	}
+	return &Rule{rule: r}, nil
+}
+
+// Next returns the first occurrence strictly after t, or false when the
+// rule has no more occurrences.
+func (r *Rule) Next(t time.Time) (time.Time, bool) {
+	next := r.rule.After(t, false)
+	if next.IsZero() {
+		return time.Time{}, false
+	}
+	return next.UTC(), true
+}
@@ -0,0 +1,87 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/store"
+)
+
+// Dispatcher periodically claims due reminders from the store and hands them
+// to a Notifier.
+//
+// A reminder is leased before it is sent and marked as sent only after the
+// notifier succeeds. If the process dies in between, the lease expires and the
+// reminder is sent again, so with a receiver that honours Reminder.Key each
+// reminder takes effect exactly once, across restarts and across several
+// server instances sharing a database.
+type Dispatcher struct {
+	Store    store.ReminderStore
+	Notifier Notifier
+	// Interval is how often the store is polled for due reminders.
+	Interval time.Duration
+	// Lease is how long a claimed reminder is hidden from other dispatchers;
+	// it should comfortably exceed the notifier's timeout.
+	Lease time.Duration
+	// BatchSize caps how many reminders are claimed per poll.
+	BatchSize int
+}
+
+func NewDispatcher(s store.ReminderStore, n Notifier) *Dispatcher {
+	return &Dispatcher{
+		Store:     s,
+		Notifier:  n,
+		Interval:  30 * time.Second,
+		Lease:     2 * time.Minute,
+		BatchSize: 100,
+	}
+}
+
+// Run dispatches reminders until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+	for {
+		if err := d.Dispatch(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Reminder dispatch failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch sends every reminder that is due now, batch by batch.
+func (d *Dispatcher) Dispatch(ctx context.Context) error {
+	for {
+		todos, err := d.Store.ClaimReminders(ctx, time.Now(), d.Lease, d.BatchSize)
+		if err != nil {
+			return err
+		}
+		for _, todo := range todos {
+			r := Reminder{
+				TodoID:   todo.ID,
+				UserID:   todo.UserId,
+				Title:    todo.Title,
+				DueAt:    todo.DueAt,
+				RemindAt: *todo.RemindAt,
+			}
+			if err := d.Notifier.Notify(ctx, r); err != nil {
+				// Left leased; it is retried once the lease expires.
+				log.Printf("Reminder %s not delivered: %v", r.Key(), err)
+				continue
+			}
+			err := d.Store.MarkReminded(ctx, todo.ID, r.RemindAt, time.Now())
+			if err != nil && !errors.Is(err, store.ErrNotFound) {
+				log.Printf("Reminder %s delivered but not recorded: %v", r.Key(), err)
+			}
+		}
+		if len(todos) < d.BatchSize {
+			return nil
+		}
+	}
+}
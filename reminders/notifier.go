@@ -0,0 +1,113 @@
+// Package reminders delivers todo reminders once their remind_at time passes,
+// and notifies users of todos assigned to them.
+package reminders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Reminder is what a Notifier delivers for a todo.
+type Reminder struct {
+	TodoID   int        `json:"todo_id"`
+	UserID   int        `json:"user_id"`
+	Title    string     `json:"title"`
+	DueAt    *time.Time `json:"due_at"`
+	RemindAt time.Time  `json:"remind_at"`
+}
+
+// Key identifies one reminder. It stays the same if delivery is retried, so
+// receivers can use it to drop duplicates.
+func (r Reminder) Key() string {
+	return fmt.Sprintf("todo-%d-%d", r.TodoID, r.RemindAt.Unix())
+}
+
+// Notifier delivers reminders. Notify should return an error only when the
+// reminder was not delivered, so that it is retried.
+type Notifier interface {
+	Notify(ctx context.Context, r Reminder) error
+}
+
+// Assignment is what an AssignmentNotifier delivers when a todo is assigned
+// to someone.
+type Assignment struct {
+	TodoID     int       `json:"todo_id"`
+	Title      string    `json:"title"`
+	AssigneeID int       `json:"assignee_id"`
+	AssignedBy int       `json:"assigned_by"`
+	AssignedAt time.Time `json:"assigned_at"`
+}
+
+// Key identifies one assignment, so receivers can drop duplicates.
+func (a Assignment) Key() string {
+	return fmt.Sprintf("assignment-%d-%d-%d", a.TodoID, a.AssigneeID, a.AssignedAt.UnixNano())
+}
+
+// AssignmentNotifier tells users about todos assigned to them. Unlike
+// reminders, assignment notifications are not retried.
+type AssignmentNotifier interface {
+	NotifyAssignment(ctx context.Context, a Assignment) error
+}
+
+// LogNotifier writes reminders and assignments to the standard logger.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, r Reminder) error {
+	log.Printf("Reminder for user %d: todo %d %q", r.UserID, r.TodoID, r.Title)
+	return nil
+}
+
+func (LogNotifier) NotifyAssignment(ctx context.Context, a Assignment) error {
+	log.Printf("User %d assigned todo %d %q to user %d", a.AssignedBy, a.TodoID, a.Title, a.AssigneeID)
+	return nil
+}
+
+// WebhookNotifier POSTs each reminder or assignment as JSON to URL, with
+// X-Todo-Event set to "reminder" or "assignment". The Key is sent in the
+// Idempotency-Key header.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, r Reminder) error {
+	return n.post(ctx, "reminder", r.Key(), r)
+}
+
+func (n *WebhookNotifier) NotifyAssignment(ctx context.Context, a Assignment) error {
+	return n.post(ctx, "assignment", a.Key(), a)
+}
+
+// post sends payload as JSON, naming the kind of event in the X-Todo-Event
+// header.
+func (n *WebhookNotifier) post(ctx context.Context, event, key string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+	req.Header.Set("X-Todo-Event", event)
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
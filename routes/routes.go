@@ -3,25 +3,108 @@ package routes
 import (
 	"net/http"
 
+	"github.com/Anwarjondev/todo-api-go/blobs"
 	"github.com/Anwarjondev/todo-api-go/handlers"
 	"github.com/Anwarjondev/todo-api-go/middleware"
+	"github.com/Anwarjondev/todo-api-go/reminders"
+	"github.com/Anwarjondev/todo-api-go/store"
 )
 
-func SetupRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("POST /register", handlers.Register)
-	mux.HandleFunc("POST /login", handlers.Login)
+// SetupRoutes registers every endpoint on mux. assignments, which may be nil,
+// is told when todos are assigned.
+func SetupRoutes(mux *http.ServeMux, s store.Store, files blobs.BlobStore, assignments reminders.AssignmentNotifier) {
+	auth := handlers.NewAuthHandler(s, s)
+	todos := handlers.NewTodoHandler(s, s, s, s, files, s, s, s, s, s)
+	todos.Assignments = assignments
+	attachments := handlers.NewAttachmentHandler(s, s, s, files)
+	comments := handlers.NewCommentHandler(s, s, s)
+	projects := handlers.NewProjectHandler(s, s)
+	tags := handlers.NewTagHandler(s, s)
+	statuses := handlers.NewStatusHandler(s, s)
+	dependencies := handlers.NewDependencyHandler(s, s, s)
+	timeEntries := handlers.NewTimeEntryHandler(s, s, s)
+	shares := handlers.NewShareHandler(s, s, s)
+	trash := handlers.NewTrashHandler(s, s, files)
+	admin := handlers.NewAdminHandler(s)
+
+	mux.HandleFunc("POST /register", auth.Register)
+	mux.HandleFunc("POST /login", auth.Login)
+	mux.HandleFunc("POST /token/refresh", auth.RefreshToken)
+	mux.HandleFunc("POST /logout", auth.Logout)
 
 	protectedMux := http.NewServeMux()
-	protectedMux.HandleFunc("GET /todos", handlers.GetTodos)
-	protectedMux.HandleFunc("POST /todos/create", handlers.CreateTodo)
-	protectedMux.HandleFunc("PUT /todos/update", handlers.UpdateTodo)
-	protectedMux.HandleFunc("DELETE /todos/delete", handlers.DeleteTodo)
+	protectedMux.HandleFunc("GET /todos", todos.GetTodos)
+	protectedMux.HandleFunc("POST /todos", todos.CreateTodo)
+	protectedMux.HandleFunc("POST /todos/quick", todos.QuickAddTodo)
+	protectedMux.HandleFunc("GET /todos/today", todos.GetToday)
+	protectedMux.HandleFunc("GET /todos/{id}", todos.GetTodo)
+	protectedMux.HandleFunc("PUT /todos/{id}", todos.UpdateTodo)
+	protectedMux.HandleFunc("PATCH /todos/{id}", todos.PatchTodo)
+	protectedMux.HandleFunc("DELETE /todos/{id}", todos.DeleteTodo)
+	protectedMux.HandleFunc("POST /todos/{id}/move", todos.MoveTodo)
+	protectedMux.HandleFunc("GET /todos/{id}/subtasks", todos.GetSubtasks)
+	protectedMux.HandleFunc("GET /todos/{id}/history", todos.GetHistory)
+	protectedMux.HandleFunc("POST /todos/{id}/revert", todos.RevertTodo)
+	protectedMux.HandleFunc("GET /todos/{id}/attachments", attachments.GetAttachments)
+	protectedMux.HandleFunc("POST /todos/{id}/attachments", attachments.UploadAttachment)
+	protectedMux.HandleFunc("GET /todos/{id}/attachments/{attachmentID}", attachments.DownloadAttachment)
+	protectedMux.HandleFunc("DELETE /todos/{id}/attachments/{attachmentID}", attachments.DeleteAttachment)
+	protectedMux.HandleFunc("GET /todos/{id}/comments", comments.GetComments)
+	protectedMux.HandleFunc("POST /todos/{id}/comments", comments.CreateComment)
+	protectedMux.HandleFunc("PUT /todos/{id}/comments/{commentID}", comments.UpdateComment)
+	protectedMux.HandleFunc("DELETE /todos/{id}/comments/{commentID}", comments.DeleteComment)
+	protectedMux.HandleFunc("PUT /todos/{id}/blockers/{blockerID}", dependencies.AddBlocker)
+	protectedMux.HandleFunc("DELETE /todos/{id}/blockers/{blockerID}", dependencies.RemoveBlocker)
+	protectedMux.HandleFunc("POST /todos/{id}/timer/start", timeEntries.StartTimer)
+	protectedMux.HandleFunc("POST /todos/{id}/timer/stop", timeEntries.StopTimer)
+	protectedMux.HandleFunc("GET /todos/{id}/time-entries", timeEntries.GetTimeEntries)
+	protectedMux.HandleFunc("POST /todos/{id}/time-entries", timeEntries.CreateTimeEntry)
+	protectedMux.HandleFunc("PUT /todos/{id}/tags/{tagID}", tags.AttachTag)
+	protectedMux.HandleFunc("DELETE /todos/{id}/tags/{tagID}", tags.DetachTag)
+
+	protectedMux.HandleFunc("GET /projects", projects.GetProjects)
+	protectedMux.HandleFunc("POST /projects", projects.CreateProject)
+	protectedMux.HandleFunc("GET /projects/{id}", projects.GetProject)
+	protectedMux.HandleFunc("PATCH /projects/{id}", projects.PatchProject)
+	protectedMux.HandleFunc("DELETE /projects/{id}", projects.DeleteProject)
+	protectedMux.HandleFunc("GET /projects/{id}/todos", projects.GetProjectTodos)
+
+	protectedMux.HandleFunc("GET /statuses", statuses.GetStatuses)
+	protectedMux.HandleFunc("POST /statuses", statuses.CreateStatus)
+	protectedMux.HandleFunc("PATCH /statuses/{id}", statuses.PatchStatus)
+	protectedMux.HandleFunc("DELETE /statuses/{id}", statuses.DeleteStatus)
+	protectedMux.HandleFunc("GET /board", statuses.GetBoard)
+
+	protectedMux.HandleFunc("GET /timer", timeEntries.GetTimer)
+	protectedMux.HandleFunc("PATCH /time-entries/{id}", timeEntries.PatchTimeEntry)
+	protectedMux.HandleFunc("DELETE /time-entries/{id}", timeEntries.DeleteTimeEntry)
+	protectedMux.HandleFunc("GET /time-report", timeEntries.GetTimeReport)
+
+	protectedMux.HandleFunc("GET /trash", trash.GetTrash)
+	protectedMux.HandleFunc("POST /trash/{id}/restore", trash.RestoreTodo)
+	protectedMux.HandleFunc("DELETE /trash/{id}", trash.PurgeTodo)
+
+	protectedMux.HandleFunc("GET /shares", shares.GetShares)
+	protectedMux.HandleFunc("POST /shares", shares.CreateShare)
+	protectedMux.HandleFunc("DELETE /shares/{id}", shares.DeleteShare)
+
+	protectedMux.HandleFunc("GET /tags", tags.GetTags)
+	protectedMux.HandleFunc("POST /tags", tags.CreateTag)
+	protectedMux.HandleFunc("PUT /tags/{id}", tags.RenameTag)
+	protectedMux.HandleFunc("DELETE /tags/{id}", tags.DeleteTag)
+
+	// Deprecated verb-shaped aliases of the routes above.
+	protectedMux.HandleFunc("POST /todos/create", middleware.Deprecated("/todos", todos.CreateTodoLegacy))
+	protectedMux.HandleFunc("PUT /todos/update", middleware.Deprecated("/todos/{id}", todos.UpdateTodoLegacy))
+	protectedMux.HandleFunc("DELETE /todos/delete", middleware.Deprecated("/todos/{id}", todos.DeleteTodoLegacy))
 
 	adminmux := http.NewServeMux()
-	adminmux.HandleFunc("DELETE /admin/todos", handlers.DeleteAllTodos)
-	adminmux.HandleFunc("GET /admin/getallusers", handlers.GetAllUsers)
+	adminmux.HandleFunc("DELETE /admin/todos", todos.DeleteAllTodos)
+	adminmux.HandleFunc("GET /admin/getallusers", admin.GetAllUsers)
+	adminmux.HandleFunc("PUT /admin/users/{id}/role", admin.SetUserRole)
+	adminmux.HandleFunc("GET /admin/role-changes", admin.GetRoleChanges)
+
+	mux.Handle("/", middleware.AuthMiddleware(s, protectedMux))
+	mux.Handle("/admin/", middleware.AuthMiddleware(s, middleware.AdminMiddleware(adminmux)))
 
-	mux.Handle("/", middleware.AuthMiddleware(protectedMux))
-	mux.Handle("/admin/", middleware.AuthMiddleware(middleware.AdminMiddleware(adminmux)))
-	
 }
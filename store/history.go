@@ -0,0 +1,64 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx that attributes the todo changes made with
+// it to userID in the history.
+func WithActor(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, actorKey{}, userID)
+}
+
+// actorID returns the user set on ctx by WithActor, or nil for changes made
+// by the server itself.
+func actorID(ctx context.Context) *int {
+	if userID, ok := ctx.Value(actorKey{}).(int); ok {
+		return &userID
+	}
+	return nil
+}
+
+// historyFields are the JSON names of the todo fields whose changes are
+// recorded in the history.
+var historyFields = []string{
+	"title", "completed", "status_id", "assignee_id", "project_id", "parent_id",
+	"rank", "priority", "due_at", "remind_at", "rrule", "timezone", "blocked_by", "deleted_at",
+}
+
+// todoChanges returns the history fields that differ between before and
+// after; before is nil for a new todo.
+func todoChanges(before *models.Todo, after models.Todo) map[string]models.FieldChange {
+	from, to := historyValues(before), historyValues(&after)
+	changes := map[string]models.FieldChange{}
+	for _, field := range historyFields {
+		if !bytes.Equal(from[field], to[field]) {
+			changes[field] = models.FieldChange{From: from[field], To: to[field]}
+		}
+	}
+	return changes
+}
+
+// historyValues returns the JSON values of the history fields of todo, with
+// null for unset fields and for every field of a nil todo.
+func historyValues(todo *models.Todo) map[string]json.RawMessage {
+	values := map[string]json.RawMessage{}
+	if todo != nil {
+		// Encoding a todo cannot fail, and decoding it back into a map
+		// neither.
+		raw, _ := json.Marshal(todo)
+		json.Unmarshal(raw, &values)
+	}
+	for _, field := range historyFields {
+		if values[field] == nil {
+			values[field] = json.RawMessage("null")
+		}
+	}
+	return values
+}
@@ -0,0 +1,402 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+// MemoryStore is a Store that keeps everything in process memory. It is meant
+// for tests and for running the API without a database.
+type MemoryStore struct {
+	mu               sync.RWMutex
+	todos            map[int]models.Todo
+	leases           map[int]time.Time
+	projects         map[int]models.Project
+	series           map[int]models.Series
+	tags             map[int]models.Tag
+	todoTags         map[int]map[int]bool
+	dependencies     map[int]map[int]bool
+	attachments      map[int]models.Attachment
+	comments         map[int]models.Comment
+	timeEntries      map[int]models.TimeEntry
+	shares           map[int]models.Share
+	statuses         map[int]models.Status
+	users            map[int]models.User
+	tokens           map[int]models.RefreshToken
+	roleChanges      []models.RoleChange
+	history          map[int][]models.HistoryEntry
+	nextTodoID       int
+	nextUserID       int
+	nextTokenID      int
+	nextTagID        int
+	nextProjectID    int
+	nextSeriesID     int
+	nextAttachmentID int
+	nextCommentID    int
+	nextTimeEntryID  int
+	nextShareID      int
+	nextStatusID     int
+	nextHistoryID    int
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		todos:        make(map[int]models.Todo),
+		leases:       make(map[int]time.Time),
+		projects:     make(map[int]models.Project),
+		series:       make(map[int]models.Series),
+		tags:         make(map[int]models.Tag),
+		todoTags:     make(map[int]map[int]bool),
+		dependencies: make(map[int]map[int]bool),
+		attachments:  make(map[int]models.Attachment),
+		comments:     make(map[int]models.Comment),
+		timeEntries:  make(map[int]models.TimeEntry),
+		shares:       make(map[int]models.Share),
+		statuses:     make(map[int]models.Status),
+		users:        make(map[int]models.User),
+		tokens:       make(map[int]models.RefreshToken),
+		history:      make(map[int][]models.HistoryEntry),
+	}
+}
+
+func (s *MemoryStore) ListTodos(ctx context.Context, filter TodoFilter) ([]models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var todos []models.Todo
+	for _, todo := range s.todos {
+		if todo.DeletedAt != nil {
+			continue
+		}
+		if filter.UserID != 0 && todo.UserId != filter.UserID {
+			continue
+		}
+		if filter.VisibleTo != 0 && todo.UserId != filter.VisibleTo && s.shareRole(todo, filter.VisibleTo) == "" {
+			continue
+		}
+		if filter.AssigneeID != 0 && (todo.AssigneeID == nil || *todo.AssigneeID != filter.AssigneeID) {
+			continue
+		}
+		if filter.StatusID != 0 && todo.StatusID != filter.StatusID {
+			continue
+		}
+		if filter.ProjectID != 0 && todo.ProjectID != filter.ProjectID {
+			continue
+		}
+		if filter.Completed != nil && todo.Completed != *filter.Completed {
+			continue
+		}
+		if filter.DueFrom != nil && (todo.DueAt == nil || todo.DueAt.Before(*filter.DueFrom)) {
+			continue
+		}
+		if filter.DueBefore != nil && (todo.DueAt == nil || !todo.DueAt.Before(*filter.DueBefore)) {
+			continue
+		}
+		todo = s.withRelated(todo)
+		if !matchesTags(todo, filter) {
+			continue
+		}
+		todos = append(todos, todo)
+	}
+	sort.Slice(todos, func(i, j int) bool { return compareTodos(todos[i], todos[j], filter) < 0 })
+	if filter.After != nil {
+		i := sort.Search(len(todos), func(i int) bool { return compareTodos(todos[i], *filter.After, filter) > 0 })
+		todos = todos[i:]
+	}
+	if filter.Limit > 0 && len(todos) > filter.Limit {
+		todos = todos[:filter.Limit]
+	}
+	return todos, nil
+}
+
+// compareTodos orders a and b the way SQLStore.ListTodos orders filter's
+// result, returning a negative number when a comes first.
+func compareTodos(a, b models.Todo, filter TodoFilter) int {
+	var c int
+	switch filter.Sort {
+	case SortByRank:
+		c = strings.Compare(a.Rank, b.Rank)
+	case SortByTitle:
+		c = strings.Compare(a.Title, b.Title)
+	case SortByCreated:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	case SortByDue:
+		if (a.DueAt == nil) != (b.DueAt == nil) {
+			// No due date sorts last whatever the direction.
+			if a.DueAt == nil {
+				return 1
+			}
+			return -1
+		}
+		if a.DueAt != nil {
+			c = a.DueAt.Compare(*b.DueAt)
+		}
+	}
+	if c == 0 {
+		c = a.ID - b.ID
+	}
+	if filter.Desc {
+		return -c
+	}
+	return c
+}
+
+func (s *MemoryStore) GetTodo(ctx context.Context, id int) (models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	todo, ok := s.todos[id]
+	if !ok || todo.DeletedAt != nil {
+		return models.Todo{}, ErrNotFound
+	}
+	return s.withRelated(todo), nil
+}
+
+func (s *MemoryStore) CreateTodo(ctx context.Context, todo *models.Todo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.occurrenceExists(*todo) {
+		return ErrOccurrenceExists
+	}
+	s.todoStatus(todo)
+	if err := s.lastRank(todo); err != nil {
+		return err
+	}
+	if todo.Priority == "" {
+		todo.Priority = models.PriorityNone
+	}
+	s.nextTodoID++
+	todo.ID = s.nextTodoID
+	todo.CreatedAt = dbTime(time.Now())
+	todo.Version = 1
+	s.todos[todo.ID] = *todo
+	s.recordHistory(ctx, models.HistoryCreated, nil, todo.ID)
+	s.cascadeCompletion(ctx, todo.ID, todo.Completed)
+	return nil
+}
+
+func (s *MemoryStore) UpdateTodo(ctx context.Context, todo *models.Todo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.todos[todo.ID]
+	if !ok || existing.DeletedAt != nil {
+		return ErrNotFound
+	}
+	if existing.Version != todo.Version {
+		return ErrVersionConflict
+	}
+	if s.occurrenceExists(*todo) {
+		return ErrOccurrenceExists
+	}
+	todo.Version++
+	todo.CreatedAt = existing.CreatedAt
+	todo.Rank = existing.Rank
+	todo.DeletedAt = existing.DeletedAt
+	s.todoStatus(todo)
+	s.todos[todo.ID] = *todo
+	s.recordHistory(ctx, models.HistoryUpdated, &existing, todo.ID)
+	s.cascadeCompletion(ctx, todo.ID, todo.Completed)
+	return nil
+}
+
+func (s *MemoryStore) DeleteTodo(ctx context.Context, id int, version int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	todo, ok := s.todos[id]
+	if !ok || todo.DeletedAt != nil {
+		return ErrNotFound
+	}
+	if todo.Version != version {
+		return ErrVersionConflict
+	}
+	now := dbTime(time.Now())
+	// Subtasks already in the trash keep their own DeletedAt, so they are not
+	// restored along with this todo.
+	ids := append(s.subtaskIDs(id), id)
+	sort.Ints(ids)
+	for _, todoID := range ids {
+		if t := s.todos[todoID]; t.DeletedAt == nil {
+			before := t
+			t.DeletedAt = &now
+			t.Version++
+			s.todos[todoID] = t
+			s.recordHistory(ctx, models.HistoryDeleted, &before, todoID)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) ClaimReminders(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.Todo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []models.Todo
+	for _, todo := range s.todos {
+		if todo.RemindAt == nil || todo.RemindAt.After(now) || todo.RemindedAt != nil || todo.Completed || todo.DeletedAt != nil {
+			continue
+		}
+		if until, ok := s.leases[todo.ID]; ok && until.After(now) {
+			continue
+		}
+		due = append(due, todo)
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].RemindAt.Before(*due[j].RemindAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	for _, todo := range due {
+		s.leases[todo.ID] = now.Add(lease)
+	}
+	return due, nil
+}
+
+func (s *MemoryStore) MarkReminded(ctx context.Context, todoID int, remindAt, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	todo, ok := s.todos[todoID]
+	if !ok || todo.RemindAt == nil || !todo.RemindAt.Equal(remindAt) || todo.RemindedAt != nil {
+		return ErrNotFound
+	}
+	todo.RemindedAt = &at
+	s.todos[todoID] = todo
+	delete(s.leases, todoID)
+	return nil
+}
+
+func (s *MemoryStore) CreateUser(ctx context.Context, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.Username == user.Username {
+			return ErrUsernameTaken
+		}
+	}
+	s.nextUserID++
+	user.ID = s.nextUserID
+	s.users[user.ID] = *user
+	s.createProject(&models.Project{UserID: user.ID, Name: InboxName, IsInbox: true})
+	for _, status := range DefaultStatuses {
+		status.UserID = user.ID
+		s.createStatus(&status)
+	}
+	return nil
+}
+
+func (s *MemoryStore) GetUser(ctx context.Context, id int) (models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	user, ok := s.users[id]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	return user, nil
+}
+
+func (s *MemoryStore) GetUserByUsername(ctx context.Context, username string) (models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, u := range s.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return models.User{}, ErrNotFound
+}
+
+func (s *MemoryStore) ListUsers(ctx context.Context) ([]models.AllUser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var users []models.AllUser
+	for _, u := range s.users {
+		users = append(users, models.AllUser{ID: u.ID, Username: u.Username, Role: u.Role})
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+func (s *MemoryStore) SetUserRole(ctx context.Context, change *models.RoleChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[change.UserID]
+	if !ok {
+		return ErrNotFound
+	}
+	if change.NewRole != "admin" {
+		admins := 0
+		for _, u := range s.users {
+			if u.Role == "admin" && u.ID != user.ID {
+				admins++
+			}
+		}
+		if admins == 0 {
+			return ErrLastAdmin
+		}
+	}
+	change.OldRole = user.Role
+	change.ID = len(s.roleChanges) + 1
+	user.Role = change.NewRole
+	s.users[user.ID] = user
+	s.roleChanges = append(s.roleChanges, *change)
+	return nil
+}
+
+func (s *MemoryStore) ListRoleChanges(ctx context.Context, userID int) ([]models.RoleChange, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var changes []models.RoleChange
+	for _, change := range s.roleChanges {
+		if userID == 0 || change.UserID == userID {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func (s *MemoryStore) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextTokenID++
+	token.ID = s.nextTokenID
+	s.tokens[token.ID] = *token
+	return nil
+}
+
+func (s *MemoryStore) GetRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, token := range s.tokens {
+		if token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+	return models.RefreshToken{}, ErrNotFound
+}
+
+func (s *MemoryStore) UseRefreshToken(ctx context.Context, id int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[id]
+	if !ok || token.UsedAt != nil || token.RevokedAt != nil {
+		return ErrTokenUsed
+	}
+	token.UsedAt = &at
+	s.tokens[id] = token
+	return nil
+}
+
+func (s *MemoryStore) RevokeTokenFamily(ctx context.Context, familyID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, token := range s.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &at
+			s.tokens[id] = token
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+func (s *MemoryStore) ListAttachments(ctx context.Context, todoID int) ([]models.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var attachments []models.Attachment
+	for _, attachment := range s.attachments {
+		if attachment.TodoID == todoID {
+			attachments = append(attachments, attachment)
+		}
+	}
+	sort.Slice(attachments, func(i, j int) bool { return attachments[i].ID < attachments[j].ID })
+	return attachments, nil
+}
+
+func (s *MemoryStore) GetAttachment(ctx context.Context, id int) (models.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	attachment, ok := s.attachments[id]
+	if !ok {
+		return models.Attachment{}, ErrNotFound
+	}
+	return attachment, nil
+}
+
+func (s *MemoryStore) CreateAttachment(ctx context.Context, attachment *models.Attachment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.todos[attachment.TodoID]; !ok {
+		return ErrNotFound
+	}
+	s.nextAttachmentID++
+	attachment.ID = s.nextAttachmentID
+	attachment.CreatedAt = dbTime(attachment.CreatedAt)
+	s.attachments[attachment.ID] = *attachment
+	return nil
+}
+
+func (s *MemoryStore) DeleteAttachment(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.attachments[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.attachments, id)
+	return nil
+}
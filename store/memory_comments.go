@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+func (s *MemoryStore) ListComments(ctx context.Context, todoID int) ([]models.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var comments []models.Comment
+	for _, comment := range s.comments {
+		if comment.TodoID == todoID {
+			comments = append(comments, s.withAuthor(comment))
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool { return comments[i].ID < comments[j].ID })
+	return comments, nil
+}
+
+func (s *MemoryStore) GetComment(ctx context.Context, id int) (models.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	comment, ok := s.comments[id]
+	if !ok {
+		return models.Comment{}, ErrNotFound
+	}
+	return s.withAuthor(comment), nil
+}
+
+func (s *MemoryStore) CreateComment(ctx context.Context, comment *models.Comment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.todos[comment.TodoID]; !ok {
+		return ErrNotFound
+	}
+	s.nextCommentID++
+	comment.ID = s.nextCommentID
+	comment.CreatedAt = dbTime(comment.CreatedAt)
+	comment.UpdatedAt = comment.CreatedAt
+	*comment = s.withAuthor(*comment)
+	s.comments[comment.ID] = *comment
+	return nil
+}
+
+func (s *MemoryStore) UpdateComment(ctx context.Context, comment *models.Comment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.comments[comment.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.Body = comment.Body
+	stored.UpdatedAt = dbTime(comment.UpdatedAt)
+	s.comments[comment.ID] = stored
+	*comment = s.withAuthor(stored)
+	return nil
+}
+
+func (s *MemoryStore) DeleteComment(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.comments[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.comments, id)
+	return nil
+}
+
+// withAuthor fills in the username of the comment's author. Callers hold s.mu.
+func (s *MemoryStore) withAuthor(comment models.Comment) models.Comment {
+	comment.Author = s.users[comment.UserID].Username
+	return comment
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+func (s *MemoryStore) AddDependency(ctx context.Context, todoID, blockerID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.todos[todoID]; !ok {
+		return ErrNotFound
+	}
+	if _, ok := s.todos[blockerID]; !ok {
+		return ErrNotFound
+	}
+	if blockerID == todoID || s.blocks(todoID, blockerID) {
+		return ErrDependencyCycle
+	}
+	if s.dependencies[todoID][blockerID] {
+		return nil
+	}
+	before := s.withDependencies(s.todos[todoID])
+	if s.dependencies[todoID] == nil {
+		s.dependencies[todoID] = map[int]bool{}
+	}
+	s.dependencies[todoID][blockerID] = true
+	s.blockersChanged(ctx, before)
+	return nil
+}
+
+func (s *MemoryStore) RemoveDependency(ctx context.Context, todoID, blockerID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dependencies[todoID][blockerID] {
+		return ErrNotFound
+	}
+	before := s.withDependencies(s.todos[todoID])
+	delete(s.dependencies[todoID], blockerID)
+	s.blockersChanged(ctx, before)
+	return nil
+}
+
+// blockersChanged adds one to the version of a todo whose blockers are no
+// longer those of before, and records the change in its history. Callers
+// hold s.mu for writing.
+func (s *MemoryStore) blockersChanged(ctx context.Context, before models.Todo) {
+	todo := s.todos[before.ID]
+	todo.Version++
+	s.todos[todo.ID] = todo
+	s.recordChange(ctx, models.HistoryUpdated, &before, s.withDependencies(todo))
+}
+
+// blocks reports whether blockerID blocks todoID, directly or through other
+// todos. Callers hold s.mu.
+func (s *MemoryStore) blocks(blockerID, todoID int) bool {
+	seen := map[int]bool{}
+	queue := []int{todoID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for b := range s.dependencies[id] {
+			if b == blockerID {
+				return true
+			}
+			if !seen[b] {
+				seen[b] = true
+				queue = append(queue, b)
+			}
+		}
+	}
+	return false
+}
+
+// withDependencies returns todo with its BlockedBy and Blocked filled in.
+// Callers hold s.mu.
+func (s *MemoryStore) withDependencies(todo models.Todo) models.Todo {
+	todo.BlockedBy = []int{}
+	todo.Blocked = false
+	for blockerID := range s.dependencies[todo.ID] {
+		blocker := s.todos[blockerID]
+		if blocker.DeletedAt != nil {
+			continue
+		}
+		todo.BlockedBy = append(todo.BlockedBy, blockerID)
+		todo.Blocked = todo.Blocked || !blocker.Completed
+	}
+	sort.Ints(todo.BlockedBy)
+	return todo
+}
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+func (s *MemoryStore) ListHistory(ctx context.Context, todoID int) ([]models.HistoryEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.HistoryEntry(nil), s.history[todoID]...), nil
+}
+
+// recordHistory records action in the history of the todo with the given
+// id, comparing it as it is now with before, which is nil for a new todo.
+// Blockers are left out, as only dependency changes alter them and those
+// record them with recordChange. Callers hold s.mu for writing.
+func (s *MemoryStore) recordHistory(ctx context.Context, action string, before *models.Todo, id int) {
+	after := s.withRecurrence(s.todos[id])
+	after.BlockedBy = nil
+	if before != nil {
+		b := s.withRecurrence(*before)
+		b.BlockedBy = nil
+		before = &b
+	}
+	s.recordChange(ctx, action, before, after)
+}
+
+// recordChange records action in the history of after, comparing it with
+// before. Updates that change none of the recorded fields are left out.
+// Callers hold s.mu for writing.
+func (s *MemoryStore) recordChange(ctx context.Context, action string, before *models.Todo, after models.Todo) {
+	changes := todoChanges(before, after)
+	if len(changes) == 0 && action == models.HistoryUpdated {
+		return
+	}
+	s.nextHistoryID++
+	s.history[after.ID] = append(s.history[after.ID], models.HistoryEntry{
+		ID:        s.nextHistoryID,
+		TodoID:    after.ID,
+		ActorID:   actorID(ctx),
+		Action:    action,
+		Version:   after.Version,
+		Changes:   changes,
+		CreatedAt: dbTime(time.Now()),
+	})
+}
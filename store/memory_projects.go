@@ -0,0 +1,115 @@
+package store
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+func (s *MemoryStore) ListProjects(ctx context.Context, userID int, includeArchived bool) ([]models.Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	projects := []models.Project{}
+	for _, p := range s.projects {
+		if p.UserID == userID && (includeArchived || !p.Archived) {
+			projects = append(projects, p)
+		}
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		if projects[i].Position != projects[j].Position {
+			return projects[i].Position < projects[j].Position
+		}
+		return projects[i].ID < projects[j].ID
+	})
+	return projects, nil
+}
+
+func (s *MemoryStore) GetProject(ctx context.Context, id int) (models.Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.projects[id]
+	if !ok {
+		return models.Project{}, ErrNotFound
+	}
+	return p, nil
+}
+
+func (s *MemoryStore) GetInbox(ctx context.Context, userID int) (models.Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.inbox(userID)
+	if !ok {
+		return models.Project{}, ErrNotFound
+	}
+	return p, nil
+}
+
+func (s *MemoryStore) CreateProject(ctx context.Context, p *models.Project) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.createProject(p)
+	return nil
+}
+
+func (s *MemoryStore) UpdateProject(ctx context.Context, p *models.Project) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.projects[p.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	existing.Name = p.Name
+	existing.Color = p.Color
+	existing.Archived = p.Archived
+	existing.Position = p.Position
+	s.projects[p.ID] = existing
+	return nil
+}
+
+func (s *MemoryStore) DeleteProject(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.projects[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if p.IsInbox {
+		return ErrInboxProject
+	}
+	inbox, _ := s.inbox(p.UserID)
+	for todoID, todo := range s.todos {
+		if todo.ProjectID == id {
+			before := todo
+			todo.ProjectID = inbox.ID
+			todo.Version++
+			s.todos[todoID] = todo
+			s.recordHistory(ctx, models.HistoryUpdated, &before, todoID)
+		}
+	}
+	for seriesID, series := range s.series {
+		if series.ProjectID == id {
+			series.ProjectID = inbox.ID
+			s.series[seriesID] = series
+		}
+	}
+	delete(s.projects, id)
+	return nil
+}
+
+// createProject stores p under a new id. Callers hold s.mu.
+func (s *MemoryStore) createProject(p *models.Project) {
+	s.nextProjectID++
+	p.ID = s.nextProjectID
+	s.projects[p.ID] = *p
+}
+
+// inbox returns userID's Inbox project. Callers hold s.mu.
+func (s *MemoryStore) inbox(userID int) (models.Project, bool) {
+	for _, p := range s.projects {
+		if p.UserID == userID && p.IsInbox {
+			return p, true
+		}
+	}
+	return models.Project{}, false
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/rank"
+)
+
+func (s *MemoryStore) MoveTodo(ctx context.Context, id int, key string, version int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	todo, ok := s.todos[id]
+	if !ok || todo.DeletedAt != nil {
+		return ErrNotFound
+	}
+	if todo.Version != version {
+		return ErrVersionConflict
+	}
+	before := todo
+	todo.Rank = key
+	todo.Version++
+	s.todos[id] = todo
+	s.recordHistory(ctx, models.HistoryUpdated, &before, id)
+	return nil
+}
+
+func (s *MemoryStore) UnbalancedRanks(ctx context.Context, maxLength int) ([]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	seen := map[int]bool{}
+	var users []int
+	for _, todo := range s.todos {
+		if (todo.Rank == "" || len(todo.Rank) > maxLength) && !seen[todo.UserId] {
+			seen[todo.UserId] = true
+			users = append(users, todo.UserId)
+		}
+	}
+	sort.Ints(users)
+	return users, nil
+}
+
+func (s *MemoryStore) RebalanceRanks(ctx context.Context, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var todos []models.Todo
+	for _, todo := range s.todos {
+		if todo.UserId == userID {
+			todos = append(todos, todo)
+		}
+	}
+	sort.Slice(todos, func(i, j int) bool { return compareTodos(todos[i], todos[j], TodoFilter{Sort: SortByRank}) < 0 })
+	for i, key := range rank.Spread(len(todos)) {
+		todos[i].Rank = key
+		s.todos[todos[i].ID] = todos[i]
+	}
+	return nil
+}
+
+// lastRank ranks todo after every other todo of its owner, unless it already
+// has a rank. Callers hold s.mu.
+func (s *MemoryStore) lastRank(todo *models.Todo) error {
+	if todo.Rank != "" {
+		return nil
+	}
+	last := ""
+	for _, t := range s.todos {
+		if t.UserId == todo.UserId && t.Rank > last {
+			last = t.Rank
+		}
+	}
+	var err error
+	todo.Rank, err = rank.Next(last)
+	return err
+}
@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+func (s *MemoryStore) GetSeries(ctx context.Context, id int) (models.Series, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	series, ok := s.series[id]
+	if !ok {
+		return models.Series{}, ErrNotFound
+	}
+	return series, nil
+}
+
+func (s *MemoryStore) CreateSeries(ctx context.Context, series *models.Series) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextSeriesID++
+	series.ID = s.nextSeriesID
+	series.DTStart = dbTime(series.DTStart)
+	s.series[series.ID] = *series
+	return nil
+}
+
+// occurrenceExists reports whether another todo in todo's series is due at
+// the same time. Callers hold s.mu.
+func (s *MemoryStore) occurrenceExists(todo models.Todo) bool {
+	if todo.SeriesID == nil || todo.DueAt == nil {
+		return false
+	}
+	for _, t := range s.todos {
+		if t.ID != todo.ID && t.SeriesID != nil && *t.SeriesID == *todo.SeriesID &&
+			t.DueAt != nil && t.DueAt.Equal(*todo.DueAt) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+func (s *MemoryStore) ListShares(ctx context.Context, userID int) ([]models.Share, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var shares []models.Share
+	for _, share := range s.shares {
+		if share.OwnerID == userID || share.GranteeID == userID {
+			shares = append(shares, s.withUsernames(share))
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool { return shares[i].ID < shares[j].ID })
+	return shares, nil
+}
+
+func (s *MemoryStore) GetShare(ctx context.Context, id int) (models.Share, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	share, ok := s.shares[id]
+	if !ok {
+		return models.Share{}, ErrNotFound
+	}
+	return s.withUsernames(share), nil
+}
+
+func (s *MemoryStore) CreateShare(ctx context.Context, share *models.Share) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if share.TodoID != nil {
+		if _, ok := s.todos[*share.TodoID]; !ok {
+			return ErrNotFound
+		}
+	}
+	for _, other := range s.shares {
+		if other.GranteeID == share.GranteeID && sameTodo(other.TodoID, share.TodoID) &&
+			(share.TodoID != nil || other.OwnerID == share.OwnerID) {
+			return ErrShareExists
+		}
+	}
+	s.nextShareID++
+	share.ID = s.nextShareID
+	share.CreatedAt = dbTime(share.CreatedAt)
+	*share = s.withUsernames(*share)
+	s.shares[share.ID] = *share
+	return nil
+}
+
+func (s *MemoryStore) DeleteShare(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.shares[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.shares, id)
+	return nil
+}
+
+func (s *MemoryStore) ShareRole(ctx context.Context, todoID, userID int) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	todo, ok := s.todos[todoID]
+	if !ok {
+		return "", nil
+	}
+	return s.shareRole(todo, userID), nil
+}
+
+// shareRole returns the strongest role userID was granted on todo, or "".
+// Callers hold s.mu.
+func (s *MemoryStore) shareRole(todo models.Todo, userID int) string {
+	covered := map[int]bool{}
+	for t, ok := todo, true; ok; {
+		covered[t.ID] = true
+		if t.ParentID == nil || covered[*t.ParentID] {
+			break
+		}
+		t, ok = s.todos[*t.ParentID]
+	}
+	role := ""
+	for _, share := range s.shares {
+		if share.GranteeID != userID {
+			continue
+		}
+		if (share.TodoID == nil && share.OwnerID == todo.UserId) || (share.TodoID != nil && covered[*share.TodoID]) {
+			if share.Role == models.ShareEditor || role == "" {
+				role = share.Role
+			}
+		}
+	}
+	return role
+}
+
+// withUsernames fills in the usernames of the share's owner and grantee.
+// Callers hold s.mu.
+func (s *MemoryStore) withUsernames(share models.Share) models.Share {
+	share.Owner = s.users[share.OwnerID].Username
+	share.Grantee = s.users[share.GranteeID].Username
+	return share
+}
+
+// sameTodo reports whether two optional todo ids are equal.
+func sameTodo(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,160 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+func (s *MemoryStore) ListStatuses(ctx context.Context, userID int) ([]models.Status, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.userStatuses(userID), nil
+}
+
+func (s *MemoryStore) GetStatus(ctx context.Context, id int) (models.Status, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	status, ok := s.statuses[id]
+	if !ok {
+		return models.Status{}, ErrNotFound
+	}
+	return withTransitions(status), nil
+}
+
+func (s *MemoryStore) CreateStatus(ctx context.Context, status *models.Status) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.statusNameTaken(status.UserID, status.Name, 0) {
+		return ErrStatusExists
+	}
+	s.createStatus(status)
+	return nil
+}
+
+func (s *MemoryStore) UpdateStatus(ctx context.Context, status *models.Status) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.statuses[status.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if existing.IsDone != status.IsDone && s.statusInUse(status.ID) {
+		return ErrStatusInUse
+	}
+	if s.statusNameTaken(existing.UserID, status.Name, status.ID) {
+		return ErrStatusExists
+	}
+	existing.Name = status.Name
+	existing.Position = status.Position
+	existing.IsDone = status.IsDone
+	existing.Transitions = transitionSet(status.Transitions)
+	s.statuses[status.ID] = existing
+	return nil
+}
+
+func (s *MemoryStore) DeleteStatus(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.statuses[id]; !ok {
+		return ErrNotFound
+	}
+	if s.statusInUse(id) {
+		return ErrStatusInUse
+	}
+	delete(s.statuses, id)
+	for statusID, status := range s.statuses {
+		if i := slices.Index(status.Transitions, id); i >= 0 {
+			status.Transitions = slices.Delete(slices.Clone(status.Transitions), i, i+1)
+			s.statuses[statusID] = status
+		}
+	}
+	return nil
+}
+
+// createStatus stores status under a new id. Callers hold s.mu.
+func (s *MemoryStore) createStatus(status *models.Status) {
+	s.nextStatusID++
+	status.ID = s.nextStatusID
+	stored := *status
+	stored.Transitions = transitionSet(status.Transitions)
+	s.statuses[status.ID] = stored
+}
+
+// userStatuses returns userID's statuses ordered by position. Callers hold
+// s.mu.
+func (s *MemoryStore) userStatuses(userID int) []models.Status {
+	statuses := []models.Status{}
+	for _, status := range s.statuses {
+		if status.UserID == userID {
+			statuses = append(statuses, withTransitions(status))
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Position != statuses[j].Position {
+			return statuses[i].Position < statuses[j].Position
+		}
+		return statuses[i].ID < statuses[j].ID
+	})
+	return statuses
+}
+
+func (s *MemoryStore) statusNameTaken(userID int, name string, exceptID int) bool {
+	for _, status := range s.statuses {
+		if status.UserID == userID && status.Name == name && status.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryStore) statusInUse(id int) bool {
+	for _, todo := range s.todos {
+		if todo.StatusID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// todoStatus puts a todo without a status into its owner's first status
+// matching Completed, and otherwise takes Completed from its status. It
+// fills in the status name either way. Callers hold s.mu.
+func (s *MemoryStore) todoStatus(todo *models.Todo) {
+	if todo.StatusID == 0 {
+		todo.StatusID = s.firstStatus(todo.UserId, todo.Completed)
+	} else if status, ok := s.statuses[todo.StatusID]; ok {
+		todo.Completed = status.IsDone
+	}
+	todo.Status = s.statuses[todo.StatusID].Name
+}
+
+// firstStatus returns the id of userID's first status whose done flag is
+// isDone, or zero if there is none. Callers hold s.mu.
+func (s *MemoryStore) firstStatus(userID int, isDone bool) int {
+	for _, status := range s.userStatuses(userID) {
+		if status.IsDone == isDone {
+			return status.ID
+		}
+	}
+	return 0
+}
+
+// transitionSet returns a sorted copy of ids without duplicates.
+func transitionSet(ids []int) []int {
+	set := slices.Clone(ids)
+	slices.Sort(set)
+	return slices.Compact(set)
+}
+
+// withTransitions returns status with a copy of its transitions, so callers
+// cannot modify the stored slice.
+func withTransitions(status models.Status) models.Status {
+	status.Transitions = slices.Clone(status.Transitions)
+	if status.Transitions == nil {
+		status.Transitions = []int{}
+	}
+	return status
+}
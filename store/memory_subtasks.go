@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+func (s *MemoryStore) ListSubtasks(ctx context.Context, id int) ([]models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := s.subtaskIDs(id)
+	sort.Ints(ids)
+	todos := make([]models.Todo, 0, len(ids))
+	for _, todoID := range ids {
+		todos = append(todos, s.withRelated(s.todos[todoID]))
+	}
+	return todos, nil
+}
+
+// withRecurrence returns todo with the rrule and time zone of its series
+// filled in. Callers hold s.mu.
+func (s *MemoryStore) withRecurrence(todo models.Todo) models.Todo {
+	todo.RRule, todo.Timezone = "", ""
+	if todo.SeriesID != nil {
+		series := s.series[*todo.SeriesID]
+		todo.RRule, todo.Timezone = series.RRule, series.Timezone
+	}
+	return todo
+}
+
+// withRelated returns todo with its status name, tags, blockers, subtask progress and recurrence
+// filled in. Callers hold s.mu.
+func (s *MemoryStore) withRelated(todo models.Todo) models.Todo {
+	todo = s.withTags(todo)
+	todo = s.withDependencies(todo)
+	todo.Status = s.statuses[todo.StatusID].Name
+	todo = s.withRecurrence(todo)
+	todo.Progress = nil
+	for _, t := range s.todos {
+		if t.ParentID == nil || *t.ParentID != todo.ID || t.DeletedAt != nil {
+			continue
+		}
+		if todo.Progress == nil {
+			todo.Progress = &models.Progress{}
+		}
+		todo.Progress.Total++
+		if t.Completed {
+			todo.Progress.Done++
+		}
+	}
+	return todo
+}
+
+// subtaskIDs returns the ids of every todo below id in ascending order.
+// Callers hold s.mu.
+func (s *MemoryStore) subtaskIDs(id int) []int {
+	var ids []int
+	seen := map[int]bool{id: true}
+	queue := []int{id}
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+		for _, t := range s.todos {
+			if t.ParentID != nil && *t.ParentID == parent && !seen[t.ID] {
+				seen[t.ID] = true
+				ids = append(ids, t.ID)
+				queue = append(queue, t.ID)
+			}
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// cascadeCompletion completes every todo below id when completed is set, and
+// otherwise reopens every todo above it, moving each to its owner's first
+// status that matches. Todos in the trash are left as they are. Callers hold
+// s.mu for writing.
+func (s *MemoryStore) cascadeCompletion(ctx context.Context, id int, completed bool) {
+	if completed {
+		for _, todoID := range s.subtaskIDs(id) {
+			t := s.todos[todoID]
+			if !t.Completed && t.DeletedAt == nil {
+				before := t
+				t.Completed = true
+				t.StatusID = s.firstStatus(t.UserId, true)
+				t.Version++
+				s.todos[todoID] = t
+				s.recordHistory(ctx, models.HistoryUpdated, &before, todoID)
+			}
+		}
+		return
+	}
+	seen := map[int]bool{id: true}
+	for t := s.todos[id]; t.ParentID != nil && !seen[*t.ParentID]; {
+		seen[*t.ParentID] = true
+		parent, ok := s.todos[*t.ParentID]
+		if !ok {
+			return
+		}
+		if parent.Completed && parent.DeletedAt == nil {
+			before := parent
+			parent.Completed = false
+			parent.StatusID = s.firstStatus(parent.UserId, false)
+			parent.Version++
+			s.todos[parent.ID] = parent
+			s.recordHistory(ctx, models.HistoryUpdated, &before, parent.ID)
+		}
+		t = parent
+	}
+}
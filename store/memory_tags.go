@@ -0,0 +1,137 @@
+package store
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+func (s *MemoryStore) ListTags(ctx context.Context, userID int) ([]models.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tags := []models.Tag{}
+	for _, tag := range s.tags {
+		if tag.UserID == userID {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+func (s *MemoryStore) GetTag(ctx context.Context, id int) (models.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tag, ok := s.tags[id]
+	if !ok {
+		return models.Tag{}, ErrNotFound
+	}
+	return tag, nil
+}
+
+func (s *MemoryStore) CreateTag(ctx context.Context, tag *models.Tag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tagNameTaken(tag.UserID, tag.Name, 0) {
+		return ErrTagExists
+	}
+	s.nextTagID++
+	tag.ID = s.nextTagID
+	s.tags[tag.ID] = *tag
+	return nil
+}
+
+func (s *MemoryStore) RenameTag(ctx context.Context, id int, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tag, ok := s.tags[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if s.tagNameTaken(tag.UserID, name, id) {
+		return ErrTagExists
+	}
+	tag.Name = name
+	s.tags[id] = tag
+	return nil
+}
+
+func (s *MemoryStore) DeleteTag(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tags[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.tags, id)
+	for _, tagIDs := range s.todoTags {
+		delete(tagIDs, id)
+	}
+	return nil
+}
+
+func (s *MemoryStore) AttachTag(ctx context.Context, todoID, tagID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.todos[todoID]; !ok {
+		return ErrNotFound
+	}
+	if _, ok := s.tags[tagID]; !ok {
+		return ErrNotFound
+	}
+	if s.todoTags[todoID] == nil {
+		s.todoTags[todoID] = map[int]bool{}
+	}
+	s.todoTags[todoID][tagID] = true
+	return nil
+}
+
+func (s *MemoryStore) DetachTag(ctx context.Context, todoID, tagID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.todoTags[todoID][tagID] {
+		return ErrNotFound
+	}
+	delete(s.todoTags[todoID], tagID)
+	return nil
+}
+
+func (s *MemoryStore) tagNameTaken(userID int, name string, exceptID int) bool {
+	for _, tag := range s.tags {
+		if tag.UserID == userID && tag.Name == name && tag.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+// withTags returns todo with its tags filled in. Callers hold s.mu.
+func (s *MemoryStore) withTags(todo models.Todo) models.Todo {
+	todo.Tags = []models.Tag{}
+	for tagID := range s.todoTags[todo.ID] {
+		todo.Tags = append(todo.Tags, s.tags[tagID])
+	}
+	sort.Slice(todo.Tags, func(i, j int) bool { return todo.Tags[i].Name < todo.Tags[j].Name })
+	return todo
+}
+
+// matchesTags reports whether todo passes the tag part of filter. Callers
+// hold s.mu and pass a todo returned by withTags.
+func matchesTags(todo models.Todo, filter TodoFilter) bool {
+	if len(filter.Tags) == 0 {
+		return true
+	}
+	has := map[string]bool{}
+	for _, tag := range todo.Tags {
+		has[tag.Name] = true
+	}
+	for _, name := range filter.Tags {
+		if has[name] && !filter.MatchAllTags {
+			return true
+		}
+		if !has[name] && filter.MatchAllTags {
+			return false
+		}
+	}
+	return filter.MatchAllTags
+}
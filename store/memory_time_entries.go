@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+func (s *MemoryStore) ListTimeEntries(ctx context.Context, filter TimeEntryFilter) ([]models.TimeEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var entries []models.TimeEntry
+	for _, e := range s.timeEntries {
+		if filter.UserID != 0 && e.UserID != filter.UserID {
+			continue
+		}
+		if filter.TodoID != 0 && e.TodoID != filter.TodoID {
+			continue
+		}
+		if filter.From != nil && e.StartedAt.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && !e.StartedAt.Before(*filter.To) {
+			continue
+		}
+		entries = append(entries, s.withTodoTitle(e))
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].StartedAt.Equal(entries[j].StartedAt) {
+			return entries[i].StartedAt.Before(entries[j].StartedAt)
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	return entries, nil
+}
+
+func (s *MemoryStore) GetTimeEntry(ctx context.Context, id int) (models.TimeEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.timeEntries[id]
+	if !ok {
+		return models.TimeEntry{}, ErrNotFound
+	}
+	return s.withTodoTitle(e), nil
+}
+
+func (s *MemoryStore) RunningTimeEntry(ctx context.Context, userID int) (models.TimeEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.runningTimeEntry(userID)
+	if !ok {
+		return models.TimeEntry{}, ErrNotFound
+	}
+	return s.withTodoTitle(e), nil
+}
+
+func (s *MemoryStore) CreateTimeEntry(ctx context.Context, entry *models.TimeEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.todos[entry.TodoID]; !ok {
+		return ErrNotFound
+	}
+	if _, running := s.runningTimeEntry(entry.UserID); running && entry.StoppedAt == nil {
+		return ErrTimerRunning
+	}
+	s.nextTimeEntryID++
+	entry.ID = s.nextTimeEntryID
+	entry.StartedAt = dbTime(entry.StartedAt)
+	entry.StoppedAt = normalizedTime(entry.StoppedAt)
+	*entry = s.withTodoTitle(*entry)
+	s.timeEntries[entry.ID] = *entry
+	return nil
+}
+
+func (s *MemoryStore) UpdateTimeEntry(ctx context.Context, entry *models.TimeEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.timeEntries[entry.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if running, ok := s.runningTimeEntry(existing.UserID); ok && running.ID != entry.ID && entry.StoppedAt == nil {
+		return ErrTimerRunning
+	}
+	existing.StartedAt = dbTime(entry.StartedAt)
+	existing.StoppedAt = normalizedTime(entry.StoppedAt)
+	existing.Note = entry.Note
+	s.timeEntries[entry.ID] = existing
+	return nil
+}
+
+func (s *MemoryStore) DeleteTimeEntry(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.timeEntries[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.timeEntries, id)
+	return nil
+}
+
+// runningTimeEntry returns userID's running timer. Callers hold s.mu.
+func (s *MemoryStore) runningTimeEntry(userID int) (models.TimeEntry, bool) {
+	for _, e := range s.timeEntries {
+		if e.UserID == userID && e.StoppedAt == nil {
+			return e, true
+		}
+	}
+	return models.TimeEntry{}, false
+}
+
+// withTodoTitle returns e with the title of its todo. Callers hold s.mu.
+func (s *MemoryStore) withTodoTitle(e models.TimeEntry) models.TimeEntry {
+	e.TodoTitle = s.todos[e.TodoID].Title
+	return e
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+func (s *MemoryStore) ListTrash(ctx context.Context, userID int) ([]models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var todos []models.Todo
+	for _, todo := range s.todos {
+		if todo.UserId == userID && todo.DeletedAt != nil {
+			todos = append(todos, s.withRelated(todo))
+		}
+	}
+	sort.Slice(todos, func(i, j int) bool {
+		if !todos[i].DeletedAt.Equal(*todos[j].DeletedAt) {
+			return todos[i].DeletedAt.After(*todos[j].DeletedAt)
+		}
+		return todos[i].ID < todos[j].ID
+	})
+	return todos, nil
+}
+
+func (s *MemoryStore) GetTrashedTodo(ctx context.Context, id int) (models.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	todo, ok := s.todos[id]
+	if !ok || todo.DeletedAt == nil {
+		return models.Todo{}, ErrNotFound
+	}
+	return s.withRelated(todo), nil
+}
+
+func (s *MemoryStore) RestoreTodo(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	todo, ok := s.todos[id]
+	if !ok || todo.DeletedAt == nil {
+		return ErrNotFound
+	}
+	if todo.ParentID != nil && s.todos[*todo.ParentID].DeletedAt != nil {
+		return ErrParentTrashed
+	}
+	// The subtasks deleted along with the todo share its DeletedAt.
+	deletedAt := *todo.DeletedAt
+	ids := append(s.subtaskIDs(id), id)
+	sort.Ints(ids)
+	for _, todoID := range ids {
+		if t := s.todos[todoID]; t.DeletedAt != nil && t.DeletedAt.Equal(deletedAt) {
+			before := t
+			t.DeletedAt = nil
+			t.Version++
+			s.todos[todoID] = t
+			s.recordHistory(ctx, models.HistoryRestored, &before, todoID)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) PurgeTodo(ctx context.Context, id int) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	todo, ok := s.todos[id]
+	if !ok || todo.DeletedAt == nil {
+		return nil, ErrNotFound
+	}
+	return s.purge(append(s.subtaskIDs(id), id)), nil
+}
+
+func (s *MemoryStore) PurgeTrash(ctx context.Context, before time.Time) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// A subtask is never deleted later than its parent, so this includes
+	// every subtask of the todos being purged.
+	var ids []int
+	for _, todo := range s.todos {
+		if todo.DeletedAt != nil && todo.DeletedAt.Before(before) {
+			ids = append(ids, todo.ID)
+		}
+	}
+	return s.purge(ids), nil
+}
+
+// purge permanently deletes the todos with the given ids and every record
+// related to them, returning the blob keys of their attachments. Callers
+// hold s.mu for writing.
+func (s *MemoryStore) purge(ids []int) []string {
+	for _, todoID := range ids {
+		delete(s.todos, todoID)
+		delete(s.leases, todoID)
+		delete(s.todoTags, todoID)
+		delete(s.dependencies, todoID)
+		delete(s.history, todoID)
+	}
+	for _, blockerIDs := range s.dependencies {
+		for blockerID := range blockerIDs {
+			if _, ok := s.todos[blockerID]; !ok {
+				delete(blockerIDs, blockerID)
+			}
+		}
+	}
+	var keys []string
+	for id, attachment := range s.attachments {
+		if _, ok := s.todos[attachment.TodoID]; !ok {
+			keys = append(keys, attachment.BlobKey)
+			delete(s.attachments, id)
+		}
+	}
+	for id, comment := range s.comments {
+		if _, ok := s.todos[comment.TodoID]; !ok {
+			delete(s.comments, id)
+		}
+	}
+	for id, entry := range s.timeEntries {
+		if _, ok := s.todos[entry.TodoID]; !ok {
+			delete(s.timeEntries, id)
+		}
+	}
+	for id, share := range s.shares {
+		if share.TodoID == nil {
+			continue
+		}
+		if _, ok := s.todos[*share.TodoID]; !ok {
+			delete(s.shares, id)
+		}
+	}
+	return keys
+}
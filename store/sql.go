@@ -0,0 +1,617 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQLStore implements Store on top of a Postgres or SQLite database. Both
+// share the same schema and queries; driver only matters where their
+// behaviour differs, such as error codes.
+type SQLStore struct {
+	db     *sql.DB
+	driver string
+}
+
+var _ Store = (*SQLStore)(nil)
+
+// NewSQLStore returns a Store backed by db, which was opened with the
+// database/sql driver named driver ("postgres" or "sqlite").
+func NewSQLStore(db *sql.DB, driver string) *SQLStore {
+	return &SQLStore{db: db, driver: driver}
+}
+
+// todoColumns is the column list scanned by scanTodo.
+const todoColumns = "id, title, completed, status_id, user_id, assignee_id, project_id, parent_id, series_id, rank, priority, due_at, remind_at, reminded_at, created_at, version, deleted_at"
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTodo(row scanner) (models.Todo, error) {
+	var todo models.Todo
+	var statusID, assigneeID, projectID, parentID, seriesID sql.NullInt64
+	var dueAt, remindAt, remindedAt, deletedAt sql.NullTime
+	err := row.Scan(&todo.ID, &todo.Title, &todo.Completed, &statusID, &todo.UserId, &assigneeID, &projectID, &parentID, &seriesID, &todo.Rank, &todo.Priority, &dueAt, &remindAt, &remindedAt, &todo.CreatedAt, &todo.Version, &deletedAt)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	todo.StatusID = int(statusID.Int64)
+	todo.AssigneeID = intPtr(assigneeID)
+	todo.ProjectID = int(projectID.Int64)
+	todo.ParentID = intPtr(parentID)
+	todo.SeriesID = intPtr(seriesID)
+	todo.DueAt = timePtr(dueAt)
+	todo.RemindAt = timePtr(remindAt)
+	todo.RemindedAt = timePtr(remindedAt)
+	todo.CreatedAt = todo.CreatedAt.UTC()
+	todo.DeletedAt = timePtr(deletedAt)
+	return todo, nil
+}
+
+func scanTodos(rows *sql.Rows) ([]models.Todo, error) {
+	defer rows.Close()
+	var todos []models.Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	return todos, rows.Err()
+}
+
+func (s *SQLStore) ListTodos(ctx context.Context, filter TodoFilter) ([]models.Todo, error) {
+	var conds []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.UserID != 0 {
+		add("user_id = $%d", filter.UserID)
+	}
+	if filter.VisibleTo != 0 {
+		add(visibleTodos, filter.VisibleTo)
+	}
+	if filter.AssigneeID != 0 {
+		add("assignee_id = $%d", filter.AssigneeID)
+	}
+	if filter.StatusID != 0 {
+		add("status_id = $%d", filter.StatusID)
+	}
+	if filter.ProjectID != 0 {
+		add("project_id = $%d", filter.ProjectID)
+	}
+	if filter.Completed != nil {
+		add("completed = $%d", *filter.Completed)
+	}
+	if filter.DueFrom != nil {
+		add("due_at >= $%d", dbTime(*filter.DueFrom))
+	}
+	if filter.DueBefore != nil {
+		add("due_at < $%d", dbTime(*filter.DueBefore))
+	}
+	if len(filter.Tags) > 0 {
+		var cond string
+		cond, args = tagFilter(filter, args)
+		conds = append(conds, cond)
+	}
+	order, after, args := todoOrder(filter, args)
+	if after != "" {
+		conds = append(conds, after)
+	}
+	conds = append(conds, "deleted_at is null")
+	query := "select " + todoColumns + " from todos where " + strings.Join(conds, " and ")
+	query += " order by " + order
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" limit $%d", len(args))
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	todos, err := scanTodos(rows)
+	if err != nil {
+		return nil, err
+	}
+	return todos, s.loadRelated(ctx, todos)
+}
+
+// todoOrder returns the order by clause for filter.Sort and, when filter.After
+// is set, the keyset condition selecting the todos that follow it, with its
+// arguments appended to args.
+func todoOrder(filter TodoFilter, args []any) (order, after string, _ []any) {
+	dir, cmp := "asc", ">"
+	if filter.Desc {
+		dir, cmp = "desc", "<"
+	}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	last := filter.After
+
+	var column string
+	var value any
+	switch filter.Sort {
+	case SortByTitle:
+		column = "title"
+		if last != nil {
+			value = last.Title
+		}
+	case SortByRank:
+		column = "rank"
+		if last != nil {
+			value = last.Rank
+		}
+	case SortByCreated:
+		column = "created_at"
+		if last != nil {
+			value = dbTime(last.CreatedAt)
+		}
+	case SortByDue:
+		order = fmt.Sprintf("due_at is null, due_at %[1]s, id %[1]s", dir)
+		switch {
+		case last == nil:
+		case last.DueAt == nil:
+			after = fmt.Sprintf("(due_at is null and id %s %s)", cmp, arg(last.ID))
+		default:
+			due, id := arg(dbTime(*last.DueAt)), arg(last.ID)
+			after = fmt.Sprintf("(due_at is null or due_at %[1]s %[2]s or (due_at = %[2]s and id %[1]s %[3]s))", cmp, due, id)
+		}
+		return order, after, args
+	default:
+		order = "id " + dir
+		if last != nil {
+			after = fmt.Sprintf("id %s %s", cmp, arg(last.ID))
+		}
+		return order, after, args
+	}
+	order = fmt.Sprintf("%[1]s %[2]s, id %[2]s", column, dir)
+	if last != nil {
+		v, id := arg(value), arg(last.ID)
+		after = fmt.Sprintf("(%[1]s %[2]s %[3]s or (%[1]s = %[3]s and id %[2]s %[4]s))", column, cmp, v, id)
+	}
+	return order, after, args
+}
+
+func (s *SQLStore) GetTodo(ctx context.Context, id int) (models.Todo, error) {
+	todo, err := scanTodo(s.db.QueryRowContext(ctx, "select "+todoColumns+" from todos where id = $1 and deleted_at is null", id))
+	if err == sql.ErrNoRows {
+		return models.Todo{}, ErrNotFound
+	} else if err != nil {
+		return models.Todo{}, err
+	}
+	todos := []models.Todo{todo}
+	if err := s.loadRelated(ctx, todos); err != nil {
+		return models.Todo{}, err
+	}
+	return todos[0], nil
+}
+
+// loadRelated fills in the status name, tags, blockers, subtask progress and
+// recurrence of every todo.
+func (s *SQLStore) loadRelated(ctx context.Context, todos []models.Todo) error {
+	if err := s.loadStatusNames(ctx, todos); err != nil {
+		return err
+	}
+	if err := s.loadTags(ctx, todos); err != nil {
+		return err
+	}
+	if err := s.loadDependencies(ctx, todos); err != nil {
+		return err
+	}
+	if err := s.loadProgress(ctx, todos); err != nil {
+		return err
+	}
+	return s.loadRecurrence(ctx, todos)
+}
+
+func (s *SQLStore) CreateTodo(ctx context.Context, todo *models.Todo) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := todoStatus(ctx, tx, todo); err != nil {
+		return err
+	}
+	if err := lastRank(ctx, tx, todo); err != nil {
+		return err
+	}
+	if todo.Priority == "" {
+		todo.Priority = models.PriorityNone
+	}
+	todo.CreatedAt = dbTime(time.Now())
+	todo.Version = 1
+	err = tx.QueryRowContext(ctx, `insert into todos(title, completed, status_id, user_id, assignee_id, project_id, parent_id,
+		series_id, rank, priority, due_at, remind_at, created_at) values($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) returning id`,
+		todo.Title, todo.Completed, nullID(todo.StatusID), todo.UserId, todo.AssigneeID, todo.ProjectID, todo.ParentID, todo.SeriesID,
+		todo.Rank, todo.Priority, nullTime(todo.DueAt), nullTime(todo.RemindAt), todo.CreatedAt).Scan(&todo.ID)
+	if isUniqueViolation(err) {
+		return ErrOccurrenceExists
+	} else if err != nil {
+		return err
+	}
+	if err := recordHistory(ctx, tx, models.HistoryCreated, nil, todo.ID); err != nil {
+		return err
+	}
+	if err := cascadeCompletion(ctx, tx, todo.ID, todo.Completed); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) UpdateTodo(ctx context.Context, todo *models.Todo) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := scanTodo(tx.QueryRowContext(ctx, "select "+todoColumns+" from todos where id = $1 and deleted_at is null", todo.ID))
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	if before.Version != todo.Version {
+		return ErrVersionConflict
+	}
+	if err := todoStatus(ctx, tx, todo); err != nil {
+		return err
+	}
+	// The version is checked again in case the todo changed since it was read.
+	res, err := tx.ExecContext(ctx, `update todos set title = $1, completed = $2, status_id = $3, assignee_id = $4, project_id = $5,
+		parent_id = $6, series_id = $7, priority = $8, due_at = $9, remind_at = $10, reminded_at = $11, version = version + 1
+		where id = $12 and deleted_at is null and version = $13`,
+		todo.Title, todo.Completed, nullID(todo.StatusID), todo.AssigneeID, todo.ProjectID, todo.ParentID, todo.SeriesID, todo.Priority,
+		nullTime(todo.DueAt), nullTime(todo.RemindAt), nullTime(todo.RemindedAt), todo.ID, todo.Version)
+	if isUniqueViolation(err) {
+		return ErrOccurrenceExists
+	} else if err != nil {
+		return err
+	}
+	if err := expectRow(res); errors.Is(err, ErrNotFound) {
+		return ErrVersionConflict
+	} else if err != nil {
+		return err
+	}
+	if err := recordHistory(ctx, tx, models.HistoryUpdated, &before, todo.ID); err != nil {
+		return err
+	}
+	if err := cascadeCompletion(ctx, tx, todo.ID, todo.Completed); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	todo.Version++
+	return nil
+}
+
+func (s *SQLStore) ClaimReminders(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.Todo, error) {
+	// Postgres lets concurrent dispatchers skip rows another one is claiming;
+	// SQLite runs the whole statement under its database write lock.
+	lock := ""
+	if s.driver == "postgres" {
+		lock = " for update skip locked"
+	}
+	rows, err := s.db.QueryContext(ctx, `update todos set reminder_lease_until = $1
+		where id in (
+			select id from todos
+			where remind_at <= $2 and reminded_at is null and completed = false and deleted_at is null
+				and (reminder_lease_until is null or reminder_lease_until <= $2)
+			order by remind_at limit $3`+lock+`
+		)
+		returning `+todoColumns,
+		dbTime(now.Add(lease)), dbTime(now), limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanTodos(rows)
+}
+
+func (s *SQLStore) MarkReminded(ctx context.Context, todoID int, remindAt, at time.Time) error {
+	res, err := s.db.ExecContext(ctx, `update todos set reminded_at = $1, reminder_lease_until = null
+		where id = $2 and remind_at = $3 and reminded_at is null`,
+		dbTime(at), todoID, dbTime(remindAt))
+	if err != nil {
+		return err
+	}
+	return expectRow(res)
+}
+
+func (s *SQLStore) DeleteTodo(ctx context.Context, id int, version int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current int
+	err = tx.QueryRowContext(ctx, "select version from todos where id = $1 and deleted_at is null", id).Scan(&current)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	if current != version {
+		return ErrVersionConflict
+	}
+
+	before, err := selectTodos(ctx, tx, "select "+todoColumns+" from todos where deleted_at is null and (id = $1 or id in ("+subtreeIDs+")) order by id", id)
+	if err != nil {
+		return err
+	}
+	now := dbTime(time.Now())
+	// The version is checked again in case the todo changed since it was read.
+	res, err := tx.ExecContext(ctx, "update todos set deleted_at = $2, version = version + 1 where id = $1 and deleted_at is null and version = $3", id, now, version)
+	if err != nil {
+		return err
+	}
+	if err := expectRow(res); errors.Is(err, ErrNotFound) {
+		return ErrVersionConflict
+	} else if err != nil {
+		return err
+	}
+	// Subtasks already in the trash keep their own deleted_at, so they are
+	// not restored along with this todo.
+	_, err = tx.ExecContext(ctx, "update todos set deleted_at = $2, version = version + 1 where deleted_at is null and id in ("+subtreeIDs+")", id, now)
+	if err != nil {
+		return err
+	}
+	if err := recordHistories(ctx, tx, models.HistoryDeleted, before); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) CreateUser(ctx context.Context, user *models.User) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, "insert into users(username, password, role) values($1, $2, $3) returning id",
+		user.Username, user.Password, user.Role).Scan(&user.ID)
+	if isUniqueViolation(err) {
+		return ErrUsernameTaken
+	} else if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "insert into projects(user_id, name, is_inbox) values($1, $2, true)", user.ID, InboxName)
+	if err != nil {
+		return err
+	}
+	for _, status := range DefaultStatuses {
+		status.UserID = user.ID
+		if err := createStatus(ctx, tx, &status); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) GetUser(ctx context.Context, id int) (models.User, error) {
+	var user models.User
+	err := s.db.QueryRowContext(ctx, "select id, username, password, role from users where id = $1", id).
+		Scan(&user.ID, &user.Username, &user.Password, &user.Role)
+	if err == sql.ErrNoRows {
+		return models.User{}, ErrNotFound
+	}
+	return user, err
+}
+
+func (s *SQLStore) GetUserByUsername(ctx context.Context, username string) (models.User, error) {
+	var user models.User
+	err := s.db.QueryRowContext(ctx, "select id, username, password, role from users where username = $1", username).
+		Scan(&user.ID, &user.Username, &user.Password, &user.Role)
+	if err == sql.ErrNoRows {
+		return models.User{}, ErrNotFound
+	}
+	return user, err
+}
+
+func (s *SQLStore) ListUsers(ctx context.Context) ([]models.AllUser, error) {
+	rows, err := s.db.QueryContext(ctx, "select id, username, role from users order by id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var users []models.AllUser
+	for rows.Next() {
+		var user models.AllUser
+		if err := rows.Scan(&user.ID, &user.Username, &user.Role); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (s *SQLStore) SetUserRole(ctx context.Context, change *models.RoleChange) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Under read committed, two admins demoting each other at once would
+	// each still count the other, so role changes take turns. SQLite
+	// transactions already do, as they take the database write lock when they
+	// begin.
+	if s.driver == "postgres" {
+		if _, err := tx.ExecContext(ctx, "select pg_advisory_xact_lock(hashtext('users_role'))"); err != nil {
+			return err
+		}
+	}
+	err = tx.QueryRowContext(ctx, "select role from users where id = $1", change.UserID).Scan(&change.OldRole)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, `update users set role = $1 where id = $2
+		and ($1 = 'admin' or (select count(*) from users where role = 'admin' and id <> $2) > 0)`,
+		change.NewRole, change.UserID)
+	if err != nil {
+		return err
+	}
+	if err := expectRow(res); errors.Is(err, ErrNotFound) {
+		return ErrLastAdmin
+	} else if err != nil {
+		return err
+	}
+	err = tx.QueryRowContext(ctx, `insert into role_changes(user_id, old_role, new_role, changed_by, changed_at)
+		values($1, $2, $3, $4, $5) returning id`,
+		change.UserID, change.OldRole, change.NewRole, change.ChangedBy, dbTime(change.ChangedAt)).Scan(&change.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) ListRoleChanges(ctx context.Context, userID int) ([]models.RoleChange, error) {
+	query := "select id, user_id, old_role, new_role, changed_by, changed_at from role_changes"
+	var args []any
+	if userID != 0 {
+		query += " where user_id = $1"
+		args = append(args, userID)
+	}
+	rows, err := s.db.QueryContext(ctx, query+" order by id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var changes []models.RoleChange
+	for rows.Next() {
+		var change models.RoleChange
+		var changedBy sql.NullInt64
+		if err := rows.Scan(&change.ID, &change.UserID, &change.OldRole, &change.NewRole, &changedBy, &change.ChangedAt); err != nil {
+			return nil, err
+		}
+		if changedBy.Valid {
+			id := int(changedBy.Int64)
+			change.ChangedBy = &id
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+func (s *SQLStore) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	return s.db.QueryRowContext(ctx, `insert into refresh_tokens(user_id, family_id, token_hash, created_at, expires_at)
+		values($1, $2, $3, $4, $5) returning id`,
+		token.UserID, token.FamilyID, token.TokenHash, dbTime(token.CreatedAt), dbTime(token.ExpiresAt)).Scan(&token.ID)
+}
+
+func (s *SQLStore) GetRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	var token models.RefreshToken
+	var usedAt, revokedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, `select id, user_id, family_id, token_hash, created_at, expires_at, used_at, revoked_at
+		from refresh_tokens where token_hash = $1`, tokenHash).
+		Scan(&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash, &token.CreatedAt, &token.ExpiresAt, &usedAt, &revokedAt)
+	if err == sql.ErrNoRows {
+		return models.RefreshToken{}, ErrNotFound
+	} else if err != nil {
+		return models.RefreshToken{}, err
+	}
+	token.UsedAt = timePtr(usedAt)
+	token.RevokedAt = timePtr(revokedAt)
+	return token, nil
+}
+
+func (s *SQLStore) UseRefreshToken(ctx context.Context, id int, at time.Time) error {
+	res, err := s.db.ExecContext(ctx, "update refresh_tokens set used_at = $1 where id = $2 and used_at is null and revoked_at is null",
+		dbTime(at), id)
+	if err != nil {
+		return err
+	}
+	if err := expectRow(res); errors.Is(err, ErrNotFound) {
+		return ErrTokenUsed
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *SQLStore) RevokeTokenFamily(ctx context.Context, familyID string, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, "update refresh_tokens set revoked_at = $1 where family_id = $2 and revoked_at is null",
+		dbTime(at), familyID)
+	return err
+}
+
+// timePtr converts a nullable timestamp column to the *time.Time the models use.
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// intPtr converts a nullable id column into an optional id.
+func intPtr(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	id := int(n.Int64)
+	return &id
+}
+
+// dbTime normalizes t before it is written or compared. SQLite stores times as
+// text, so every value must use the same zone and precision for comparisons to
+// work; microseconds match what Postgres keeps.
+func dbTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+// nullTime is the nullable form of dbTime.
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: dbTime(*t), Valid: true}
+}
+
+// isUniqueViolation reports whether err is a unique constraint failure from
+// either supported driver.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+	}
+	return false
+}
+
+// expectRow turns an update or delete that touched no rows into ErrNotFound.
+func expectRow(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+const attachmentColumns = "id, todo_id, user_id, filename, content_type, size, blob_key, created_at"
+
+func scanAttachment(row scanner) (models.Attachment, error) {
+	var a models.Attachment
+	err := row.Scan(&a.ID, &a.TodoID, &a.UserID, &a.Filename, &a.ContentType, &a.Size, &a.BlobKey, &a.CreatedAt)
+	a.CreatedAt = a.CreatedAt.UTC()
+	return a, err
+}
+
+func (s *SQLStore) ListAttachments(ctx context.Context, todoID int) ([]models.Attachment, error) {
+	rows, err := s.db.QueryContext(ctx, "select "+attachmentColumns+" from attachments where todo_id = $1 order by id", todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var attachments []models.Attachment
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+func (s *SQLStore) GetAttachment(ctx context.Context, id int) (models.Attachment, error) {
+	a, err := scanAttachment(s.db.QueryRowContext(ctx, "select "+attachmentColumns+" from attachments where id = $1", id))
+	if err == sql.ErrNoRows {
+		return models.Attachment{}, ErrNotFound
+	}
+	return a, err
+}
+
+func (s *SQLStore) CreateAttachment(ctx context.Context, attachment *models.Attachment) error {
+	attachment.CreatedAt = dbTime(attachment.CreatedAt)
+	return s.db.QueryRowContext(ctx, `insert into attachments(todo_id, user_id, filename, content_type, size, blob_key, created_at)
+		values($1, $2, $3, $4, $5, $6, $7) returning id`,
+		attachment.TodoID, attachment.UserID, attachment.Filename, attachment.ContentType,
+		attachment.Size, attachment.BlobKey, attachment.CreatedAt).Scan(&attachment.ID)
+}
+
+func (s *SQLStore) DeleteAttachment(ctx context.Context, id int) error {
+	res, err := s.db.ExecContext(ctx, "delete from attachments where id = $1", id)
+	if err != nil {
+		return err
+	}
+	return expectRow(res)
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+const commentQuery = `select c.id, c.todo_id, c.user_id, u.username, c.body, c.created_at, c.updated_at
+	from comments c join users u on u.id = c.user_id`
+
+func scanComment(row scanner) (models.Comment, error) {
+	var c models.Comment
+	err := row.Scan(&c.ID, &c.TodoID, &c.UserID, &c.Author, &c.Body, &c.CreatedAt, &c.UpdatedAt)
+	c.CreatedAt, c.UpdatedAt = c.CreatedAt.UTC(), c.UpdatedAt.UTC()
+	return c, err
+}
+
+func (s *SQLStore) ListComments(ctx context.Context, todoID int) ([]models.Comment, error) {
+	rows, err := s.db.QueryContext(ctx, commentQuery+" where c.todo_id = $1 order by c.id", todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var comments []models.Comment
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+func (s *SQLStore) GetComment(ctx context.Context, id int) (models.Comment, error) {
+	c, err := scanComment(s.db.QueryRowContext(ctx, commentQuery+" where c.id = $1", id))
+	if err == sql.ErrNoRows {
+		return models.Comment{}, ErrNotFound
+	}
+	return c, err
+}
+
+func (s *SQLStore) CreateComment(ctx context.Context, comment *models.Comment) error {
+	comment.CreatedAt = dbTime(comment.CreatedAt)
+	comment.UpdatedAt = comment.CreatedAt
+	err := s.db.QueryRowContext(ctx, `insert into comments(todo_id, user_id, body, created_at, updated_at)
+		values($1, $2, $3, $4, $5) returning id`,
+		comment.TodoID, comment.UserID, comment.Body, comment.CreatedAt, comment.UpdatedAt).Scan(&comment.ID)
+	if err != nil {
+		return err
+	}
+	return s.db.QueryRowContext(ctx, "select username from users where id = $1", comment.UserID).Scan(&comment.Author)
+}
+
+func (s *SQLStore) UpdateComment(ctx context.Context, comment *models.Comment) error {
+	comment.UpdatedAt = dbTime(comment.UpdatedAt)
+	res, err := s.db.ExecContext(ctx, "update comments set body = $1, updated_at = $2 where id = $3",
+		comment.Body, comment.UpdatedAt, comment.ID)
+	if err != nil {
+		return err
+	}
+	return expectRow(res)
+}
+
+func (s *SQLStore) DeleteComment(ctx context.Context, id int) error {
+	res, err := s.db.ExecContext(ctx, "delete from comments where id = $1", id)
+	if err != nil {
+		return err
+	}
+	return expectRow(res)
+}
@@ -0,0 +1,158 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+// blockerIDs selects the ids of every todo that blocks the todo whose id is
+// $1, directly or through other todos.
+const blockerIDs = `with recursive blockers(id) as (
+		select blocker_id from todo_dependencies where todo_id = $1
+		union select d.blocker_id from todo_dependencies d join blockers b on d.todo_id = b.id
+	) select id from blockers`
+
+func (s *SQLStore) AddDependency(ctx context.Context, todoID, blockerID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Two transactions adding A→B and B→A would each find no cycle and both
+	// commit, so dependency writes take turns. SQLite transactions already
+	// do, as they take the database write lock when they begin.
+	if s.driver == "postgres" {
+		if _, err := tx.ExecContext(ctx, "select pg_advisory_xact_lock(hashtext('todo_dependencies'))"); err != nil {
+			return err
+		}
+	}
+	cycle := todoID == blockerID
+	if !cycle {
+		err = tx.QueryRowContext(ctx, "select $2 in ("+blockerIDs+")", blockerID, todoID).Scan(&cycle)
+		if err != nil {
+			return err
+		}
+	}
+	if cycle {
+		return ErrDependencyCycle
+	}
+	before, err := todoWithBlockers(ctx, tx, todoID)
+	if err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, "insert into todo_dependencies(todo_id, blocker_id) values($1, $2) on conflict do nothing",
+		todoID, blockerID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+	if err := blockersChanged(ctx, tx, before); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) RemoveDependency(ctx context.Context, todoID, blockerID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := todoWithBlockers(ctx, tx, todoID)
+	if err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, "delete from todo_dependencies where todo_id = $1 and blocker_id = $2", todoID, blockerID)
+	if err != nil {
+		return err
+	}
+	if err := expectRow(res); err != nil {
+		return err
+	}
+	if err := blockersChanged(ctx, tx, before); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// todoWithBlockers returns the row of the todo with the given id, with its
+// BlockedBy filled in.
+func todoWithBlockers(ctx context.Context, tx *sql.Tx, id int) (models.Todo, error) {
+	todo, err := scanTodo(tx.QueryRowContext(ctx, "select "+todoColumns+" from todos where id = $1", id))
+	if err == sql.ErrNoRows {
+		return models.Todo{}, ErrNotFound
+	} else if err != nil {
+		return models.Todo{}, err
+	}
+	rows, err := tx.QueryContext(ctx, `select d.blocker_id from todo_dependencies d join todos t on t.id = d.blocker_id
+		where t.deleted_at is null and d.todo_id = $1 order by d.blocker_id`, id)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	defer rows.Close()
+	todo.BlockedBy = []int{}
+	for rows.Next() {
+		var blockerID int
+		if err := rows.Scan(&blockerID); err != nil {
+			return models.Todo{}, err
+		}
+		todo.BlockedBy = append(todo.BlockedBy, blockerID)
+	}
+	return todo, rows.Err()
+}
+
+// blockersChanged adds one to the version of a todo whose blockers are no
+// longer those of before, and records the change in its history.
+func blockersChanged(ctx context.Context, tx *sql.Tx, before models.Todo) error {
+	if _, err := tx.ExecContext(ctx, "update todos set version = version + 1 where id = $1", before.ID); err != nil {
+		return err
+	}
+	after, err := todoWithBlockers(ctx, tx, before.ID)
+	if err != nil {
+		return err
+	}
+	return recordChange(ctx, tx, models.HistoryUpdated, &before, after)
+}
+
+// loadDependencies sets the BlockedBy and Blocked of every todo.
+func (s *SQLStore) loadDependencies(ctx context.Context, todos []models.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+	byID := make(map[int]*models.Todo, len(todos))
+	placeholders := make([]string, len(todos))
+	args := make([]any, len(todos))
+	for i := range todos {
+		todos[i].BlockedBy = []int{}
+		todos[i].Blocked = false
+		byID[todos[i].ID] = &todos[i]
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = todos[i].ID
+	}
+	rows, err := s.db.QueryContext(ctx, `select d.todo_id, d.blocker_id, t.completed
+		from todo_dependencies d join todos t on t.id = d.blocker_id
+		where t.deleted_at is null and d.todo_id in (`+strings.Join(placeholders, ", ")+`) order by d.blocker_id`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var todoID, blockerID int
+		var completed bool
+		if err := rows.Scan(&todoID, &blockerID, &completed); err != nil {
+			return err
+		}
+		todo := byID[todoID]
+		todo.BlockedBy = append(todo.BlockedBy, blockerID)
+		todo.Blocked = todo.Blocked || !completed
+	}
+	return rows.Err()
+}
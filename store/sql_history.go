@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+func (s *SQLStore) ListHistory(ctx context.Context, todoID int) ([]models.HistoryEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `select id, todo_id, actor_id, action, version, changes, created_at
+		from todo_history where todo_id = $1 order by id`, todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []models.HistoryEntry
+	for rows.Next() {
+		var entry models.HistoryEntry
+		var actorID sql.NullInt64
+		var changes string
+		if err := rows.Scan(&entry.ID, &entry.TodoID, &actorID, &entry.Action, &entry.Version, &changes, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(changes), &entry.Changes); err != nil {
+			return nil, err
+		}
+		entry.ActorID = intPtr(actorID)
+		entry.CreatedAt = entry.CreatedAt.UTC()
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// selectTodos returns the todos selected by query, for recording their
+// history once they have been changed.
+func selectTodos(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]models.Todo, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanTodos(rows)
+}
+
+// recordHistory records action in the history of the todo with the given id,
+// comparing its row as it is now with before, which is nil for a new todo.
+// Blockers are left out, as only dependency changes alter them and those
+// record them with recordChange.
+func recordHistory(ctx context.Context, tx *sql.Tx, action string, before *models.Todo, id int) error {
+	after, err := scanTodo(tx.QueryRowContext(ctx, "select "+todoColumns+" from todos where id = $1", id))
+	if err != nil {
+		return err
+	}
+	if err := txRecurrence(ctx, tx, &after); err != nil {
+		return err
+	}
+	if before != nil {
+		b := *before
+		b.BlockedBy = nil
+		if err := txRecurrence(ctx, tx, &b); err != nil {
+			return err
+		}
+		before = &b
+	}
+	return recordChange(ctx, tx, action, before, after)
+}
+
+// recordChange records action in the history of after, comparing it with
+// before. Updates that change none of the recorded fields are left out.
+func recordChange(ctx context.Context, tx *sql.Tx, action string, before *models.Todo, after models.Todo) error {
+	changes := todoChanges(before, after)
+	if len(changes) == 0 && action == models.HistoryUpdated {
+		return nil
+	}
+	raw, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `insert into todo_history(todo_id, actor_id, action, version, changes, created_at)
+		values($1, $2, $3, $4, $5, $6)`, after.ID, actorID(ctx), action, after.Version, string(raw), dbTime(time.Now()))
+	return err
+}
+
+// recordHistories records action in the history of each todo in before, as
+// recordHistory does.
+func recordHistories(ctx context.Context, tx *sql.Tx, action string, before []models.Todo) error {
+	for i := range before {
+		if err := recordHistory(ctx, tx, action, &before[i], before[i].ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+// InboxName is the name given to every user's Inbox project.
+const InboxName = "Inbox"
+
+const projectColumns = "id, user_id, name, color, archived, position, is_inbox"
+
+func scanProject(row scanner) (models.Project, error) {
+	var p models.Project
+	err := row.Scan(&p.ID, &p.UserID, &p.Name, &p.Color, &p.Archived, &p.Position, &p.IsInbox)
+	return p, err
+}
+
+func (s *SQLStore) ListProjects(ctx context.Context, userID int, includeArchived bool) ([]models.Project, error) {
+	query := "select " + projectColumns + " from projects where user_id = $1"
+	if !includeArchived {
+		query += " and archived = false"
+	}
+	rows, err := s.db.QueryContext(ctx, query+" order by position, id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	projects := []models.Project{}
+	for rows.Next() {
+		p, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, p)
+	}
+	return projects, rows.Err()
+}
+
+func (s *SQLStore) GetProject(ctx context.Context, id int) (models.Project, error) {
+	p, err := scanProject(s.db.QueryRowContext(ctx, "select "+projectColumns+" from projects where id = $1", id))
+	if err == sql.ErrNoRows {
+		return models.Project{}, ErrNotFound
+	}
+	return p, err
+}
+
+func (s *SQLStore) GetInbox(ctx context.Context, userID int) (models.Project, error) {
+	p, err := scanProject(s.db.QueryRowContext(ctx, "select "+projectColumns+" from projects where user_id = $1 and is_inbox = true", userID))
+	if err == sql.ErrNoRows {
+		return models.Project{}, ErrNotFound
+	}
+	return p, err
+}
+
+func (s *SQLStore) CreateProject(ctx context.Context, p *models.Project) error {
+	return s.db.QueryRowContext(ctx, `insert into projects(user_id, name, color, archived, position)
+		values($1, $2, $3, $4, $5) returning id`,
+		p.UserID, p.Name, p.Color, p.Archived, p.Position).Scan(&p.ID)
+}
+
+func (s *SQLStore) UpdateProject(ctx context.Context, p *models.Project) error {
+	res, err := s.db.ExecContext(ctx, "update projects set name = $1, color = $2, archived = $3, position = $4 where id = $5",
+		p.Name, p.Color, p.Archived, p.Position, p.ID)
+	if err != nil {
+		return err
+	}
+	return expectRow(res)
+}
+
+func (s *SQLStore) DeleteProject(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	p, err := scanProject(tx.QueryRowContext(ctx, "select "+projectColumns+" from projects where id = $1", id))
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	if p.IsInbox {
+		return ErrInboxProject
+	}
+	moved, err := selectTodos(ctx, tx, "select "+todoColumns+" from todos where project_id = $1 order by id", id)
+	if err != nil {
+		return err
+	}
+	inbox := "project_id = (select id from projects where user_id = $1 and is_inbox = true)"
+	if _, err := tx.ExecContext(ctx, "update todos set "+inbox+", version = version + 1 where project_id = $2", p.UserID, id); err != nil {
+		return err
+	}
+	if err := recordHistories(ctx, tx, models.HistoryUpdated, moved); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "update todo_series set "+inbox+" where project_id = $2", p.UserID, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "delete from projects where id = $1", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
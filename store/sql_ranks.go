@@ -0,0 +1,107 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+	"github.com/Anwarjondev/todo-api-go/rank"
+)
+
+func (s *SQLStore) MoveTodo(ctx context.Context, id int, key string, version int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := scanTodo(tx.QueryRowContext(ctx, "select "+todoColumns+" from todos where id = $1 and deleted_at is null", id))
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	if before.Version != version {
+		return ErrVersionConflict
+	}
+	res, err := tx.ExecContext(ctx, "update todos set rank = $1, version = version + 1 where id = $2 and deleted_at is null and version = $3", key, id, version)
+	if err != nil {
+		return err
+	}
+	if err := expectRow(res); errors.Is(err, ErrNotFound) {
+		return ErrVersionConflict
+	} else if err != nil {
+		return err
+	}
+	if err := recordHistory(ctx, tx, models.HistoryUpdated, &before, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) UnbalancedRanks(ctx context.Context, maxLength int) ([]int, error) {
+	rows, err := s.db.QueryContext(ctx, `select distinct user_id from todos
+		where rank = '' or length(rank) > $1 order by user_id`, maxLength)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var users []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		users = append(users, userID)
+	}
+	return users, rows.Err()
+}
+
+func (s *SQLStore) RebalanceRanks(ctx context.Context, userID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Unranked todos keep their place at the front, in id order.
+	rows, err := tx.QueryContext(ctx, "select id from todos where user_id = $1 order by rank, id", userID)
+	if err != nil {
+		return err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for i, key := range rank.Spread(len(ids)) {
+		if _, err := tx.ExecContext(ctx, "update todos set rank = $1 where id = $2", key, ids[i]); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// lastRank ranks todo after every other todo of its owner, unless it already
+// has a rank.
+func lastRank(ctx context.Context, tx *sql.Tx, todo *models.Todo) error {
+	if todo.Rank != "" {
+		return nil
+	}
+	var last string
+	err := tx.QueryRowContext(ctx, "select coalesce(max(rank), '') from todos where user_id = $1", todo.UserId).Scan(&last)
+	if err != nil {
+		return err
+	}
+	todo.Rank, err = rank.Next(last)
+	return err
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+func (s *SQLStore) GetSeries(ctx context.Context, id int) (models.Series, error) {
+	var series models.Series
+	var projectID sql.NullInt64
+	err := s.db.QueryRowContext(ctx, `select id, user_id, title, project_id, rrule, timezone, dtstart
+		from todo_series where id = $1`, id).
+		Scan(&series.ID, &series.UserID, &series.Title, &projectID, &series.RRule, &series.Timezone, &series.DTStart)
+	if err == sql.ErrNoRows {
+		return models.Series{}, ErrNotFound
+	} else if err != nil {
+		return models.Series{}, err
+	}
+	series.ProjectID = int(projectID.Int64)
+	series.DTStart = series.DTStart.UTC()
+	return series, nil
+}
+
+func (s *SQLStore) CreateSeries(ctx context.Context, series *models.Series) error {
+	return s.db.QueryRowContext(ctx, `insert into todo_series(user_id, title, project_id, rrule, timezone, dtstart)
+		values($1, $2, $3, $4, $5, $6) returning id`,
+		series.UserID, series.Title, series.ProjectID, series.RRule, series.Timezone, dbTime(series.DTStart)).Scan(&series.ID)
+}
+
+// loadRecurrence sets the RRule and Timezone of every todo in a series.
+func (s *SQLStore) loadRecurrence(ctx context.Context, todos []models.Todo) error {
+	bySeries := map[int][]*models.Todo{}
+	var placeholders []string
+	var args []any
+	for i := range todos {
+		if todos[i].SeriesID == nil {
+			continue
+		}
+		id := *todos[i].SeriesID
+		if _, ok := bySeries[id]; !ok {
+			args = append(args, id)
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+		}
+		bySeries[id] = append(bySeries[id], &todos[i])
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	rows, err := s.db.QueryContext(ctx, `select id, rrule, timezone from todo_series
+		where id in (`+strings.Join(placeholders, ", ")+`)`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var rrule, timezone string
+		if err := rows.Scan(&id, &rrule, &timezone); err != nil {
+			return err
+		}
+		for _, todo := range bySeries[id] {
+			todo.RRule, todo.Timezone = rrule, timezone
+		}
+	}
+	return rows.Err()
+}
+
+// txRecurrence sets the RRule and Timezone of todo from its series, reading
+// them within tx.
+func txRecurrence(ctx context.Context, tx *sql.Tx, todo *models.Todo) error {
+	todo.RRule, todo.Timezone = "", ""
+	if todo.SeriesID == nil {
+		return nil
+	}
+	return tx.QueryRowContext(ctx, "select rrule, timezone from todo_series where id = $1", *todo.SeriesID).
+		Scan(&todo.RRule, &todo.Timezone)
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+// visibleTodos is the ListTodos condition for TodoFilter.VisibleTo: the
+// user, whose id is the argument it is formatted with, owns the todo, has a
+// share of all its owner's todos, or has a share of it or a todo above it.
+const visibleTodos = `(user_id = $%[1]d
+	or user_id in (select owner_id from shares where grantee_id = $%[1]d and todo_id is null)
+	or id in (with recursive shared(id) as (
+			select todo_id from shares where grantee_id = $%[1]d and todo_id is not null
+			union select t.id from todos t join shared s on t.parent_id = s.id
+		) select id from shared))`
+
+const shareQuery = `select s.id, s.owner_id, o.username, s.grantee_id, g.username, s.todo_id, s.role, s.created_at
+	from shares s join users o on o.id = s.owner_id join users g on g.id = s.grantee_id`
+
+func scanShare(row scanner) (models.Share, error) {
+	var share models.Share
+	var todoID sql.NullInt64
+	err := row.Scan(&share.ID, &share.OwnerID, &share.Owner, &share.GranteeID, &share.Grantee, &todoID, &share.Role, &share.CreatedAt)
+	share.TodoID = intPtr(todoID)
+	share.CreatedAt = share.CreatedAt.UTC()
+	return share, err
+}
+
+func (s *SQLStore) ListShares(ctx context.Context, userID int) ([]models.Share, error) {
+	rows, err := s.db.QueryContext(ctx, shareQuery+" where s.owner_id = $1 or s.grantee_id = $1 order by s.id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var shares []models.Share
+	for rows.Next() {
+		share, err := scanShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+	return shares, rows.Err()
+}
+
+func (s *SQLStore) GetShare(ctx context.Context, id int) (models.Share, error) {
+	share, err := scanShare(s.db.QueryRowContext(ctx, shareQuery+" where s.id = $1", id))
+	if err == sql.ErrNoRows {
+		return models.Share{}, ErrNotFound
+	}
+	return share, err
+}
+
+func (s *SQLStore) CreateShare(ctx context.Context, share *models.Share) error {
+	share.CreatedAt = dbTime(share.CreatedAt)
+	err := s.db.QueryRowContext(ctx, `insert into shares(owner_id, grantee_id, todo_id, role, created_at)
+		values($1, $2, $3, $4, $5) returning id`,
+		share.OwnerID, share.GranteeID, share.TodoID, share.Role, share.CreatedAt).Scan(&share.ID)
+	if isUniqueViolation(err) {
+		return ErrShareExists
+	} else if err != nil {
+		return err
+	}
+	stored, err := s.GetShare(ctx, share.ID)
+	if err != nil {
+		return err
+	}
+	*share = stored
+	return nil
+}
+
+func (s *SQLStore) DeleteShare(ctx context.Context, id int) error {
+	res, err := s.db.ExecContext(ctx, "delete from shares where id = $1", id)
+	if err != nil {
+		return err
+	}
+	return expectRow(res)
+}
+
+func (s *SQLStore) ShareRole(ctx context.Context, todoID, userID int) (string, error) {
+	rows, err := s.db.QueryContext(ctx, `select role from shares where grantee_id = $2 and (
+			(todo_id is null and owner_id = (select user_id from todos where id = $1))
+			or todo_id = $1 or todo_id in (`+ancestorIDs+`))`, todoID, userID)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	role := ""
+	for rows.Next() {
+		var r string
+		if err := rows.Scan(&r); err != nil {
+			return "", err
+		}
+		if r == models.ShareEditor || role == "" {
+			role = r
+		}
+	}
+	return role, rows.Err()
+}
@@ -0,0 +1,244 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+func (s *SQLStore) ListStatuses(ctx context.Context, userID int) ([]models.Status, error) {
+	rows, err := s.db.QueryContext(ctx, `select id, user_id, name, position, is_done from statuses
+		where user_id = $1 order by position, id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	statuses := []models.Status{}
+	for rows.Next() {
+		var status models.Status
+		if err := rows.Scan(&status.ID, &status.UserID, &status.Name, &status.Position, &status.IsDone); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return statuses, s.loadTransitions(ctx, statuses)
+}
+
+func (s *SQLStore) GetStatus(ctx context.Context, id int) (models.Status, error) {
+	var status models.Status
+	err := s.db.QueryRowContext(ctx, "select id, user_id, name, position, is_done from statuses where id = $1", id).
+		Scan(&status.ID, &status.UserID, &status.Name, &status.Position, &status.IsDone)
+	if err == sql.ErrNoRows {
+		return models.Status{}, ErrNotFound
+	} else if err != nil {
+		return models.Status{}, err
+	}
+	statuses := []models.Status{status}
+	if err := s.loadTransitions(ctx, statuses); err != nil {
+		return models.Status{}, err
+	}
+	return statuses[0], nil
+}
+
+func (s *SQLStore) CreateStatus(ctx context.Context, status *models.Status) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createStatus(ctx, tx, status); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) UpdateStatus(ctx context.Context, status *models.Status) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var isDone bool
+	err = tx.QueryRowContext(ctx, "select is_done from statuses where id = $1", status.ID).Scan(&isDone)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	if isDone != status.IsDone {
+		if inUse, err := statusInUse(ctx, tx, status.ID); err != nil {
+			return err
+		} else if inUse {
+			return ErrStatusInUse
+		}
+	}
+	_, err = tx.ExecContext(ctx, "update statuses set name = $1, position = $2, is_done = $3 where id = $4",
+		status.Name, status.Position, status.IsDone, status.ID)
+	if isUniqueViolation(err) {
+		return ErrStatusExists
+	} else if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "delete from status_transitions where from_id = $1", status.ID); err != nil {
+		return err
+	}
+	if err := insertTransitions(ctx, tx, *status); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) DeleteStatus(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if inUse, err := statusInUse(ctx, tx, id); err != nil {
+		return err
+	} else if inUse {
+		return ErrStatusInUse
+	}
+	res, err := tx.ExecContext(ctx, "delete from statuses where id = $1", id)
+	if err != nil {
+		return err
+	}
+	if err := expectRow(res); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// createStatus stores status and its transitions inside tx.
+func createStatus(ctx context.Context, tx *sql.Tx, status *models.Status) error {
+	err := tx.QueryRowContext(ctx, "insert into statuses(user_id, name, position, is_done) values($1, $2, $3, $4) returning id",
+		status.UserID, status.Name, status.Position, status.IsDone).Scan(&status.ID)
+	if isUniqueViolation(err) {
+		return ErrStatusExists
+	} else if err != nil {
+		return err
+	}
+	return insertTransitions(ctx, tx, *status)
+}
+
+func insertTransitions(ctx context.Context, tx *sql.Tx, status models.Status) error {
+	for _, toID := range status.Transitions {
+		_, err := tx.ExecContext(ctx, "insert into status_transitions(from_id, to_id) values($1, $2) on conflict do nothing",
+			status.ID, toID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func statusInUse(ctx context.Context, tx *sql.Tx, id int) (bool, error) {
+	var inUse bool
+	err := tx.QueryRowContext(ctx, "select exists(select 1 from todos where status_id = $1)", id).Scan(&inUse)
+	return inUse, err
+}
+
+// loadTransitions fills in the Transitions of every status.
+func (s *SQLStore) loadTransitions(ctx context.Context, statuses []models.Status) error {
+	if len(statuses) == 0 {
+		return nil
+	}
+	byID := make(map[int]*models.Status, len(statuses))
+	placeholders := make([]string, len(statuses))
+	args := make([]any, len(statuses))
+	for i := range statuses {
+		statuses[i].Transitions = []int{}
+		byID[statuses[i].ID] = &statuses[i]
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = statuses[i].ID
+	}
+	rows, err := s.db.QueryContext(ctx, `select from_id, to_id from status_transitions
+		where from_id in (`+strings.Join(placeholders, ", ")+`) order by to_id`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var fromID, toID int
+		if err := rows.Scan(&fromID, &toID); err != nil {
+			return err
+		}
+		byID[fromID].Transitions = append(byID[fromID].Transitions, toID)
+	}
+	return rows.Err()
+}
+
+// todoStatus puts a todo without a status into its owner's first status
+// matching Completed, and otherwise takes Completed from its status. It
+// fills in the status name either way.
+func todoStatus(ctx context.Context, tx *sql.Tx, todo *models.Todo) error {
+	if todo.StatusID == 0 {
+		err := tx.QueryRowContext(ctx, `select id, name from statuses where user_id = $1 and is_done = $2
+			order by position, id limit 1`, todo.UserId, todo.Completed).Scan(&todo.StatusID, &todo.Status)
+		if err == sql.ErrNoRows {
+			todo.Status = ""
+			return nil
+		}
+		return err
+	}
+	return tx.QueryRowContext(ctx, "select is_done, name from statuses where id = $1", todo.StatusID).
+		Scan(&todo.Completed, &todo.Status)
+}
+
+// firstStatus selects the first status, by position, of the owner of the
+// todo being updated whose done flag is $1.
+const firstStatus = `(select s.id from statuses s where s.user_id = todos.user_id and s.is_done = %s
+	order by s.position, s.id limit 1)`
+
+// loadStatusNames sets the Status name of every todo that has one.
+func (s *SQLStore) loadStatusNames(ctx context.Context, todos []models.Todo) error {
+	byStatus := map[int][]*models.Todo{}
+	var placeholders []string
+	var args []any
+	for i := range todos {
+		todos[i].Status = ""
+		id := todos[i].StatusID
+		if id == 0 {
+			continue
+		}
+		if _, ok := byStatus[id]; !ok {
+			args = append(args, id)
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+		}
+		byStatus[id] = append(byStatus[id], &todos[i])
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	rows, err := s.db.QueryContext(ctx, "select id, name from statuses where id in ("+strings.Join(placeholders, ", ")+")", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return err
+		}
+		for _, todo := range byStatus[id] {
+			todo.Status = name
+		}
+	}
+	return rows.Err()
+}
+
+// nullID converts an optional id where zero means none into a nullable
+// column value.
+func nullID(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+// subtreeIDs selects the ids of every todo below the todo whose id is $1.
+// union rather than union all keeps the recursion finite even if the data
+// ever contains a cycle.
+const subtreeIDs = `with recursive subtree(id) as (
+		select id from todos where parent_id = $1
+		union select t.id from todos t join subtree s on t.parent_id = s.id
+	) select id from subtree`
+
+// ancestorIDs selects the ids of every todo above the todo whose id is $1.
+const ancestorIDs = `with recursive ancestors(id) as (
+		select parent_id from todos where id = $1
+		union select t.parent_id from todos t join ancestors a on t.id = a.id
+	) select id from ancestors`
+
+func (s *SQLStore) ListSubtasks(ctx context.Context, id int) ([]models.Todo, error) {
+	rows, err := s.db.QueryContext(ctx, "select "+todoColumns+" from todos where id in ("+subtreeIDs+") order by id", id)
+	if err != nil {
+		return nil, err
+	}
+	todos, err := scanTodos(rows)
+	if err != nil {
+		return nil, err
+	}
+	return todos, s.loadRelated(ctx, todos)
+}
+
+// cascadeCompletion completes every todo below id when completed is set, and
+// otherwise reopens every todo above it, moving each to its owner's first
+// status that matches. Todos in the trash are left as they are.
+func cascadeCompletion(ctx context.Context, tx *sql.Tx, id int, completed bool) error {
+	set := "completed = false, version = version + 1, status_id = " + fmt.Sprintf(firstStatus, "false")
+	where := "completed = true and deleted_at is null and id in (" + ancestorIDs + ")"
+	if completed {
+		set = "completed = true, version = version + 1, status_id = " + fmt.Sprintf(firstStatus, "true")
+		where = "completed = false and deleted_at is null and id in (" + subtreeIDs + ")"
+	}
+	before, err := selectTodos(ctx, tx, "select "+todoColumns+" from todos where "+where+" order by id", id)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "update todos set "+set+" where "+where, id); err != nil {
+		return err
+	}
+	return recordHistories(ctx, tx, models.HistoryUpdated, before)
+}
+
+// loadProgress sets the Progress of every todo that has subtasks.
+func (s *SQLStore) loadProgress(ctx context.Context, todos []models.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+	byID := make(map[int]*models.Todo, len(todos))
+	placeholders := make([]string, len(todos))
+	args := make([]any, len(todos))
+	for i := range todos {
+		todos[i].Progress = nil
+		byID[todos[i].ID] = &todos[i]
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = todos[i].ID
+	}
+	rows, err := s.db.QueryContext(ctx, `select parent_id, count(*), sum(case when completed then 1 else 0 end)
+		from todos where deleted_at is null and parent_id in (`+strings.Join(placeholders, ", ")+`) group by parent_id`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var parentID int
+		var progress models.Progress
+		if err := rows.Scan(&parentID, &progress.Total, &progress.Done); err != nil {
+			return err
+		}
+		byID[parentID].Progress = &progress
+	}
+	return rows.Err()
+}
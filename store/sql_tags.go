@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+func (s *SQLStore) ListTags(ctx context.Context, userID int) ([]models.Tag, error) {
+	rows, err := s.db.QueryContext(ctx, "select id, user_id, name from tags where user_id = $1 order by name", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tags := []models.Tag{}
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.UserID, &tag.Name); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+func (s *SQLStore) GetTag(ctx context.Context, id int) (models.Tag, error) {
+	var tag models.Tag
+	err := s.db.QueryRowContext(ctx, "select id, user_id, name from tags where id = $1", id).Scan(&tag.ID, &tag.UserID, &tag.Name)
+	if err == sql.ErrNoRows {
+		return models.Tag{}, ErrNotFound
+	}
+	return tag, err
+}
+
+func (s *SQLStore) CreateTag(ctx context.Context, tag *models.Tag) error {
+	err := s.db.QueryRowContext(ctx, "insert into tags(user_id, name) values($1, $2) returning id", tag.UserID, tag.Name).Scan(&tag.ID)
+	if isUniqueViolation(err) {
+		return ErrTagExists
+	}
+	return err
+}
+
+func (s *SQLStore) RenameTag(ctx context.Context, id int, name string) error {
+	res, err := s.db.ExecContext(ctx, "update tags set name = $1 where id = $2", name, id)
+	if isUniqueViolation(err) {
+		return ErrTagExists
+	} else if err != nil {
+		return err
+	}
+	return expectRow(res)
+}
+
+func (s *SQLStore) DeleteTag(ctx context.Context, id int) error {
+	res, err := s.db.ExecContext(ctx, "delete from tags where id = $1", id)
+	if err != nil {
+		return err
+	}
+	return expectRow(res)
+}
+
+func (s *SQLStore) AttachTag(ctx context.Context, todoID, tagID int) error {
+	_, err := s.db.ExecContext(ctx, "insert into todo_tags(todo_id, tag_id) values($1, $2) on conflict do nothing", todoID, tagID)
+	return err
+}
+
+func (s *SQLStore) DetachTag(ctx context.Context, todoID, tagID int) error {
+	res, err := s.db.ExecContext(ctx, "delete from todo_tags where todo_id = $1 and tag_id = $2", todoID, tagID)
+	if err != nil {
+		return err
+	}
+	return expectRow(res)
+}
+
+// tagFilter returns the condition for TodoFilter.Tags, numbering its
+// placeholders after the len(args) already in use.
+func tagFilter(filter TodoFilter, args []any) (string, []any) {
+	placeholders := make([]string, len(filter.Tags))
+	for i, name := range filter.Tags {
+		args = append(args, name)
+		placeholders[i] = fmt.Sprintf("$%d", len(args))
+	}
+	cond := `id in (select tt.todo_id from todo_tags tt join tags t on t.id = tt.tag_id
+		where t.name in (` + strings.Join(placeholders, ", ") + `) group by tt.todo_id`
+	if filter.MatchAllTags {
+		args = append(args, len(distinct(filter.Tags)))
+		cond += fmt.Sprintf(" having count(distinct t.name) = $%d", len(args))
+	}
+	return cond + ")", args
+}
+
+// loadTags fills in the Tags of every todo.
+func (s *SQLStore) loadTags(ctx context.Context, todos []models.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+	byID := make(map[int]*models.Todo, len(todos))
+	placeholders := make([]string, len(todos))
+	args := make([]any, len(todos))
+	for i := range todos {
+		todos[i].Tags = []models.Tag{}
+		byID[todos[i].ID] = &todos[i]
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = todos[i].ID
+	}
+	rows, err := s.db.QueryContext(ctx, `select tt.todo_id, t.id, t.user_id, t.name
+		from todo_tags tt join tags t on t.id = tt.tag_id
+		where tt.todo_id in (`+strings.Join(placeholders, ", ")+`) order by t.name`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var todoID int
+		var tag models.Tag
+		if err := rows.Scan(&todoID, &tag.ID, &tag.UserID, &tag.Name); err != nil {
+			return err
+		}
+		byID[todoID].Tags = append(byID[todoID].Tags, tag)
+	}
+	return rows.Err()
+}
+
+func distinct(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var out []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
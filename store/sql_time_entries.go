@@ -0,0 +1,126 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+const timeEntryQuery = `select e.id, e.todo_id, t.title, e.user_id, e.started_at, e.stopped_at, e.note
+	from time_entries e join todos t on t.id = e.todo_id`
+
+func scanTimeEntry(row scanner) (models.TimeEntry, error) {
+	var e models.TimeEntry
+	var stoppedAt sql.NullTime
+	if err := row.Scan(&e.ID, &e.TodoID, &e.TodoTitle, &e.UserID, &e.StartedAt, &stoppedAt, &e.Note); err != nil {
+		return models.TimeEntry{}, err
+	}
+	e.StartedAt = e.StartedAt.UTC()
+	if e.StoppedAt = timePtr(stoppedAt); e.StoppedAt != nil {
+		*e.StoppedAt = e.StoppedAt.UTC()
+	}
+	return e, nil
+}
+
+func (s *SQLStore) ListTimeEntries(ctx context.Context, filter TimeEntryFilter) ([]models.TimeEntry, error) {
+	var conds []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.UserID != 0 {
+		add("e.user_id = $%d", filter.UserID)
+	}
+	if filter.TodoID != 0 {
+		add("e.todo_id = $%d", filter.TodoID)
+	}
+	if filter.From != nil {
+		add("e.started_at >= $%d", dbTime(*filter.From))
+	}
+	if filter.To != nil {
+		add("e.started_at < $%d", dbTime(*filter.To))
+	}
+	query := timeEntryQuery
+	if len(conds) > 0 {
+		query += " where " + strings.Join(conds, " and ")
+	}
+	rows, err := s.db.QueryContext(ctx, query+" order by e.started_at, e.id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []models.TimeEntry
+	for rows.Next() {
+		e, err := scanTimeEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (s *SQLStore) GetTimeEntry(ctx context.Context, id int) (models.TimeEntry, error) {
+	e, err := scanTimeEntry(s.db.QueryRowContext(ctx, timeEntryQuery+" where e.id = $1", id))
+	if err == sql.ErrNoRows {
+		return models.TimeEntry{}, ErrNotFound
+	}
+	return e, err
+}
+
+func (s *SQLStore) RunningTimeEntry(ctx context.Context, userID int) (models.TimeEntry, error) {
+	e, err := scanTimeEntry(s.db.QueryRowContext(ctx, timeEntryQuery+" where e.user_id = $1 and e.stopped_at is null", userID))
+	if err == sql.ErrNoRows {
+		return models.TimeEntry{}, ErrNotFound
+	}
+	return e, err
+}
+
+func (s *SQLStore) CreateTimeEntry(ctx context.Context, entry *models.TimeEntry) error {
+	entry.StartedAt = dbTime(entry.StartedAt)
+	entry.StoppedAt = normalizedTime(entry.StoppedAt)
+	err := s.db.QueryRowContext(ctx, `insert into time_entries(user_id, todo_id, started_at, stopped_at, note)
+		values($1, $2, $3, $4, $5) returning id`,
+		entry.UserID, entry.TodoID, entry.StartedAt, nullTime(entry.StoppedAt), entry.Note).Scan(&entry.ID)
+	if isUniqueViolation(err) {
+		return ErrTimerRunning
+	} else if err != nil {
+		return err
+	}
+	return s.db.QueryRowContext(ctx, "select title from todos where id = $1", entry.TodoID).Scan(&entry.TodoTitle)
+}
+
+func (s *SQLStore) UpdateTimeEntry(ctx context.Context, entry *models.TimeEntry) error {
+	entry.StartedAt = dbTime(entry.StartedAt)
+	entry.StoppedAt = normalizedTime(entry.StoppedAt)
+	res, err := s.db.ExecContext(ctx, "update time_entries set started_at = $1, stopped_at = $2, note = $3 where id = $4",
+		entry.StartedAt, nullTime(entry.StoppedAt), entry.Note, entry.ID)
+	if isUniqueViolation(err) {
+		return ErrTimerRunning
+	} else if err != nil {
+		return err
+	}
+	return expectRow(res)
+}
+
+func (s *SQLStore) DeleteTimeEntry(ctx context.Context, id int) error {
+	res, err := s.db.ExecContext(ctx, "delete from time_entries where id = $1", id)
+	if err != nil {
+		return err
+	}
+	return expectRow(res)
+}
+
+// normalizedTime applies dbTime to an optional time.
+func normalizedTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	n := dbTime(*t)
+	return &n
+}
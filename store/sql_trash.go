@@ -0,0 +1,130 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+func (s *SQLStore) ListTrash(ctx context.Context, userID int) ([]models.Todo, error) {
+	rows, err := s.db.QueryContext(ctx, "select "+todoColumns+` from todos
+		where user_id = $1 and deleted_at is not null order by deleted_at desc, id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	todos, err := scanTodos(rows)
+	if err != nil {
+		return nil, err
+	}
+	return todos, s.loadRelated(ctx, todos)
+}
+
+func (s *SQLStore) GetTrashedTodo(ctx context.Context, id int) (models.Todo, error) {
+	todo, err := scanTodo(s.db.QueryRowContext(ctx, "select "+todoColumns+" from todos where id = $1 and deleted_at is not null", id))
+	if err == sql.ErrNoRows {
+		return models.Todo{}, ErrNotFound
+	} else if err != nil {
+		return models.Todo{}, err
+	}
+	todos := []models.Todo{todo}
+	if err := s.loadRelated(ctx, todos); err != nil {
+		return models.Todo{}, err
+	}
+	return todos[0], nil
+}
+
+func (s *SQLStore) RestoreTodo(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var parentTrashed bool
+	err = tx.QueryRowContext(ctx, `select exists(select 1 from todos p join todos t on t.parent_id = p.id
+		where t.id = $1 and p.deleted_at is not null)`, id).Scan(&parentTrashed)
+	if err != nil {
+		return err
+	}
+	if parentTrashed {
+		return ErrParentTrashed
+	}
+	// The subtasks deleted along with the todo share its deleted_at.
+	where := "deleted_at = (select deleted_at from todos where id = $1) and (id = $1 or id in (" + subtreeIDs + "))"
+	before, err := selectTodos(ctx, tx, "select "+todoColumns+" from todos where "+where+" order by id", id)
+	if err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, "update todos set deleted_at = null, version = version + 1 where "+where, id)
+	if err != nil {
+		return err
+	}
+	if err := expectRow(res); err != nil {
+		return err
+	}
+	if err := recordHistories(ctx, tx, models.HistoryRestored, before); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) PurgeTodo(ctx context.Context, id int) ([]string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	keys, err := blobKeys(ctx, tx, "select blob_key from attachments where todo_id = $1 or todo_id in ("+subtreeIDs+")", id)
+	if err != nil {
+		return nil, err
+	}
+	// Subtasks go with their parent.
+	res, err := tx.ExecContext(ctx, "delete from todos where id = $1 and deleted_at is not null", id)
+	if err != nil {
+		return nil, err
+	}
+	if err := expectRow(res); err != nil {
+		return nil, err
+	}
+	return keys, tx.Commit()
+}
+
+func (s *SQLStore) PurgeTrash(ctx context.Context, before time.Time) ([]string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// A subtask is never deleted later than its parent, so the todos
+	// selected here include every subtask of the ones being purged.
+	keys, err := blobKeys(ctx, tx, `select a.blob_key from attachments a join todos t on t.id = a.todo_id
+		where t.deleted_at < $1`, dbTime(before))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "delete from todos where deleted_at < $1", dbTime(before)); err != nil {
+		return nil, err
+	}
+	return keys, tx.Commit()
+}
+
+func blobKeys(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,378 @@
+// Package store defines the persistence interfaces the HTTP handlers depend on,
+// together with a SQL (Postgres or SQLite) and an in-memory implementation.
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist.
+	ErrNotFound = errors.New("store: not found")
+	// ErrUsernameTaken is returned by CreateUser when the username is in use.
+	ErrUsernameTaken = errors.New("store: username already taken")
+	// ErrTokenUsed is returned by UseRefreshToken when the token was already
+	// used or revoked.
+	ErrTokenUsed = errors.New("store: refresh token already used")
+	// ErrLastAdmin is returned by SetUserRole when the change would leave the
+	// system without any admin.
+	ErrLastAdmin = errors.New("store: cannot demote the last admin")
+	// ErrTagExists is returned when a user already has a tag with that name.
+	ErrTagExists = errors.New("store: tag already exists")
+	// ErrOccurrenceExists is returned when a todo would become a second
+	// occurrence of its series with the same due time.
+	ErrOccurrenceExists = errors.New("store: the series already has an occurrence due at that time")
+	// ErrShareExists is returned by CreateShare when the grantee already has
+	// a share of the same todo, or of all the owner's todos.
+	ErrShareExists = errors.New("store: already shared with that user")
+	// ErrStatusExists is returned when a user already has a status with that
+	// name.
+	ErrStatusExists = errors.New("store: status already exists")
+	// ErrStatusInUse is returned when deleting a status that todos are in, or
+	// changing whether it counts as done.
+	ErrStatusInUse = errors.New("store: status is in use")
+	// ErrDependencyCycle is returned by AddDependency when the blocker is
+	// itself blocked, directly or not, by the todo.
+	ErrDependencyCycle = errors.New("store: dependency would create a cycle")
+	// ErrTimerRunning is returned when starting a timer while the user
+	// already has one running.
+	ErrTimerRunning = errors.New("store: a timer is already running")
+	// ErrVersionConflict is returned by UpdateTodo when the todo was changed
+	// since the version being saved was read.
+	ErrVersionConflict = errors.New("store: the todo was changed by someone else")
+	// ErrParentTrashed is returned by RestoreTodo while the todo's parent is
+	// still in the trash.
+	ErrParentTrashed = errors.New("store: the parent todo is in the trash")
+	// ErrInboxProject is returned when deleting a user's Inbox project.
+	ErrInboxProject = errors.New("store: the inbox project cannot be deleted")
+)
+
+// TodoFilter narrows the todos returned by ListTodos.
+type TodoFilter struct {
+	// UserID restricts the result to one owner; zero returns every user's todos.
+	UserID int
+	// VisibleTo, when non-zero, restricts the result to the todos that user
+	// owns or was granted a share of.
+	VisibleTo int
+	// AssigneeID restricts the result to todos assigned to that user when
+	// non-zero.
+	AssigneeID int
+	// StatusID restricts the result to one status when non-zero.
+	StatusID int
+	// ProjectID restricts the result to one project when non-zero.
+	ProjectID int
+	// Completed, when set, keeps only todos with that completion state.
+	Completed *bool
+	// DueFrom and DueBefore, when set, keep only todos due in [DueFrom, DueBefore).
+	DueFrom   *time.Time
+	DueBefore *time.Time
+	// Tags keeps only todos carrying the named tags: any of them, or all of
+	// them when MatchAllTags is set.
+	Tags         []string
+	MatchAllTags bool
+
+	// Sort and Desc set the order of the result. Ties are broken by id in
+	// the same direction.
+	Sort TodoSort
+	Desc bool
+	// After, when set, keeps only the todos that come after it in that
+	// order. Only its id and sort field are used.
+	After *models.Todo
+	// Limit caps the number of todos returned when positive.
+	Limit int
+}
+
+// TodoSort names a field ListTodos can sort by.
+type TodoSort string
+
+const (
+	// SortByRank is the order the user arranged their todos in.
+	SortByRank    TodoSort = "rank"
+	SortByID      TodoSort = "id"
+	SortByTitle   TodoSort = "title"
+	SortByCreated TodoSort = "created"
+	// SortByDue puts todos without a due date last in either direction.
+	SortByDue TodoSort = "due"
+)
+
+// TodoStore persists todos. Todos form trees through ParentID, and the store
+// keeps completion consistent along them: creating or updating a todo as
+// completed also completes its subtasks, and an open todo reopens every
+// todo above it. Todos moved by the cascade go to the first status of their
+// owner that matches. Deleting a todo deletes its subtasks.
+//
+// Completed follows the status: a todo saved with a StatusID takes Completed
+// from that status's IsDone, and one saved without goes to the first status,
+// by position, whose IsDone matches Completed.
+//
+// New todos are ranked after every other todo of their owner. Only MoveTodo
+// changes a todo's rank.
+//
+// New todos start at Version 1, and every change to a todo, including those
+// made by the completion cascade, moving, deleting and restoring it and
+// changing its blockers, adds one. Delivering reminders and rebalancing ranks leave Version alone, as
+// clients cannot see or make those changes.
+type TodoStore interface {
+	ListTodos(ctx context.Context, filter TodoFilter) ([]models.Todo, error)
+	GetTodo(ctx context.Context, id int) (models.Todo, error)
+	// ListSubtasks returns every todo below id, at any depth, ordered by id,
+	// including those in the trash.
+	ListSubtasks(ctx context.Context, id int) ([]models.Todo, error)
+	CreateTodo(ctx context.Context, todo *models.Todo) error
+	// UpdateTodo saves todo if it is still at todo.Version, and sets
+	// todo.Version to the new version. It returns ErrVersionConflict when the
+	// todo has changed since.
+	UpdateTodo(ctx context.Context, todo *models.Todo) error
+	// MoveTodo sets the rank of a todo, leaving every other todo alone. Like
+	// UpdateTodo it returns ErrVersionConflict when the todo is no longer at
+	// version.
+	MoveTodo(ctx context.Context, id int, rank string, version int) error
+	// DeleteTodo moves a todo and its subtasks to the trash; see TrashStore.
+	// It returns ErrVersionConflict when the todo is no longer at version.
+	DeleteTodo(ctx context.Context, id int, version int) error
+}
+
+// TrashStore manages deleted todos. Todos in the trash are left out by every
+// TodoStore method except ListSubtasks, and keep their tags, comments,
+// attachments and other related records until they are purged. Subtasks of
+// a todo in the trash are always in the trash too.
+type TrashStore interface {
+	// ListTrash returns userID's todos in the trash, most recently deleted
+	// first.
+	ListTrash(ctx context.Context, userID int) ([]models.Todo, error)
+	// GetTrashedTodo returns a todo in the trash, or ErrNotFound.
+	GetTrashedTodo(ctx context.Context, id int) (models.Todo, error)
+	// RestoreTodo takes a todo out of the trash together with the subtasks
+	// deleted along with it.
+	RestoreTodo(ctx context.Context, id int) error
+	// PurgeTodo permanently deletes a todo in the trash and its subtasks. It
+	// returns the blob keys of their attachments, whose files are up to the
+	// caller.
+	PurgeTodo(ctx context.Context, id int) ([]string, error)
+	// PurgeTrash permanently deletes every todo moved to the trash before
+	// before, returning blob keys like PurgeTodo.
+	PurgeTrash(ctx context.Context, before time.Time) ([]string, error)
+}
+
+// HistoryStore reads the history of todos. The TodoStore, TrashStore and
+// ProjectStore methods that change todos record an entry for every todo they
+// create, change, delete or restore, in the same transaction as the change
+// and attributed to the user set on the context with WithActor. Changes the
+// server makes on its own, delivering reminders and rebalancing ranks, are
+// not recorded. Purging a todo deletes its history.
+type HistoryStore interface {
+	// ListHistory returns the history of a todo, oldest first.
+	ListHistory(ctx context.Context, todoID int) ([]models.HistoryEntry, error)
+}
+
+// RankStore maintains the rank keys of todos. It implements rank.Store.
+type RankStore interface {
+	// UnbalancedRanks returns the users with a todo whose rank is empty or
+	// longer than maxLength.
+	UnbalancedRanks(ctx context.Context, maxLength int) ([]int, error)
+	// RebalanceRanks gives userID's todos evenly spaced keys from rank.Spread,
+	// keeping their order.
+	RebalanceRanks(ctx context.Context, userID int) error
+}
+
+// SeriesStore persists the series behind recurring todos. A series is never
+// changed once created; editing future occurrences starts a new one.
+type SeriesStore interface {
+	GetSeries(ctx context.Context, id int) (models.Series, error)
+	CreateSeries(ctx context.Context, series *models.Series) error
+}
+
+// AttachmentStore persists the records of files attached to todos. Purging
+// a todo deletes the records of its attachments, but the files themselves
+// are up to the caller.
+type AttachmentStore interface {
+	// ListAttachments returns the attachments of a todo ordered by id.
+	ListAttachments(ctx context.Context, todoID int) ([]models.Attachment, error)
+	GetAttachment(ctx context.Context, id int) (models.Attachment, error)
+	CreateAttachment(ctx context.Context, attachment *models.Attachment) error
+	DeleteAttachment(ctx context.Context, id int) error
+}
+
+// DependencyStore persists which todos block which. Todos returned by
+// TodoStore carry their blockers inline, and dependencies are deleted
+// together with either todo.
+type DependencyStore interface {
+	// AddDependency records that todoID is blocked by blockerID. Adding it
+	// twice is not an error, and leaves the todo's version alone; it returns
+	// ErrDependencyCycle when blockerID is already blocked by todoID,
+	// directly or through other todos.
+	AddDependency(ctx context.Context, todoID, blockerID int) error
+	RemoveDependency(ctx context.Context, todoID, blockerID int) error
+}
+
+// TimeEntryFilter narrows the entries returned by ListTimeEntries.
+type TimeEntryFilter struct {
+	// UserID and TodoID restrict the result to one user's entries or one
+	// todo's when non-zero.
+	UserID int
+	TodoID int
+	// From and To, when set, keep only entries started in [From, To).
+	From *time.Time
+	To   *time.Time
+}
+
+// TimeEntryStore persists time tracked on todos. Entries are returned with
+// the title of their todo and are deleted together with it.
+type TimeEntryStore interface {
+	// ListTimeEntries returns the matching entries ordered by start time.
+	ListTimeEntries(ctx context.Context, filter TimeEntryFilter) ([]models.TimeEntry, error)
+	GetTimeEntry(ctx context.Context, id int) (models.TimeEntry, error)
+	// RunningTimeEntry returns userID's running timer, or ErrNotFound.
+	RunningTimeEntry(ctx context.Context, userID int) (models.TimeEntry, error)
+	// CreateTimeEntry stores an entry. It returns ErrTimerRunning for a
+	// running entry when the user already has one.
+	CreateTimeEntry(ctx context.Context, entry *models.TimeEntry) error
+	// UpdateTimeEntry changes the times and note of an entry.
+	UpdateTimeEntry(ctx context.Context, entry *models.TimeEntry) error
+	DeleteTimeEntry(ctx context.Context, id int) error
+}
+
+// CommentStore persists comments on todos. Comments are returned with the
+// author's username and are deleted together with their todo.
+type CommentStore interface {
+	// ListComments returns the comments on a todo, oldest first.
+	ListComments(ctx context.Context, todoID int) ([]models.Comment, error)
+	GetComment(ctx context.Context, id int) (models.Comment, error)
+	CreateComment(ctx context.Context, comment *models.Comment) error
+	// UpdateComment changes the body of a comment and sets its UpdatedAt.
+	UpdateComment(ctx context.Context, comment *models.Comment) error
+	DeleteComment(ctx context.Context, id int) error
+}
+
+// ShareStore persists share grants, which give other users access to an
+// owner's todos.
+type ShareStore interface {
+	// ListShares returns the shares userID granted or received, oldest first,
+	// with the usernames of owner and grantee.
+	ListShares(ctx context.Context, userID int) ([]models.Share, error)
+	GetShare(ctx context.Context, id int) (models.Share, error)
+	// CreateShare stores a share. It returns ErrShareExists when the grantee
+	// already has a share of the same todo, or of all the owner's todos.
+	CreateShare(ctx context.Context, share *models.Share) error
+	DeleteShare(ctx context.Context, id int) error
+	// ShareRole returns the strongest role userID was granted on a todo,
+	// through a share of the todo itself, of a todo above it or of all its
+	// owner's todos, or "" when there is none.
+	ShareRole(ctx context.Context, todoID, userID int) (string, error)
+}
+
+// StatusStore persists users' workflow statuses. Every user starts with
+// DefaultStatuses.
+type StatusStore interface {
+	// ListStatuses returns userID's statuses ordered by position.
+	ListStatuses(ctx context.Context, userID int) ([]models.Status, error)
+	GetStatus(ctx context.Context, id int) (models.Status, error)
+	CreateStatus(ctx context.Context, status *models.Status) error
+	// UpdateStatus changes the name, position, done flag and transitions of
+	// a status. It returns ErrStatusInUse when the done flag changes while
+	// todos are in the status.
+	UpdateStatus(ctx context.Context, status *models.Status) error
+	// DeleteStatus deletes a status and the transitions to it. It returns
+	// ErrStatusInUse while todos are in the status.
+	DeleteStatus(ctx context.Context, id int) error
+}
+
+// DefaultStatuses are the statuses created with every user.
+var DefaultStatuses = []models.Status{
+	{Name: "Backlog", Position: 0},
+	{Name: "In Progress", Position: 1},
+	{Name: "Review", Position: 2},
+	{Name: "Done", Position: 3, IsDone: true},
+}
+
+// ProjectStore persists projects. Every user has exactly one Inbox project,
+// created with the user, which todos land in unless another project is chosen.
+type ProjectStore interface {
+	// ListProjects returns userID's projects ordered by position, leaving out
+	// archived ones unless includeArchived is set.
+	ListProjects(ctx context.Context, userID int, includeArchived bool) ([]models.Project, error)
+	GetProject(ctx context.Context, id int) (models.Project, error)
+	GetInbox(ctx context.Context, userID int) (models.Project, error)
+	CreateProject(ctx context.Context, project *models.Project) error
+	UpdateProject(ctx context.Context, project *models.Project) error
+	// DeleteProject deletes a project after moving its todos and series to
+	// the owner's Inbox. It returns ErrInboxProject for the Inbox itself.
+	DeleteProject(ctx context.Context, id int) error
+}
+
+// TagStore persists tags and their assignment to todos. Todos returned by
+// TodoStore carry their tags inline.
+type TagStore interface {
+	ListTags(ctx context.Context, userID int) ([]models.Tag, error)
+	GetTag(ctx context.Context, id int) (models.Tag, error)
+	CreateTag(ctx context.Context, tag *models.Tag) error
+	RenameTag(ctx context.Context, id int, name string) error
+	DeleteTag(ctx context.Context, id int) error
+	// AttachTag adds a tag to a todo; attaching it twice is not an error.
+	AttachTag(ctx context.Context, todoID, tagID int) error
+	DetachTag(ctx context.Context, todoID, tagID int) error
+}
+
+// ReminderStore hands out due reminders to the reminder dispatcher.
+type ReminderStore interface {
+	// ClaimReminders leases up to limit open todos whose reminder is due at now
+	// and not yet sent, so no other dispatcher picks them up until the lease
+	// expires.
+	ClaimReminders(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.Todo, error)
+	// MarkReminded records that the reminder set for remindAt was delivered. It
+	// returns ErrNotFound if the todo was deleted, its reminder time changed or
+	// it was already marked.
+	MarkReminded(ctx context.Context, todoID int, remindAt, at time.Time) error
+}
+
+// UserStore persists user accounts.
+type UserStore interface {
+	// CreateUser stores a new user together with their Inbox project and
+	// DefaultStatuses.
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUser(ctx context.Context, id int) (models.User, error)
+	GetUserByUsername(ctx context.Context, username string) (models.User, error)
+	ListUsers(ctx context.Context) ([]models.AllUser, error)
+	// SetUserRole changes change.UserID's role to change.NewRole and records
+	// the change, filling in change.ID and change.OldRole.
+	SetUserRole(ctx context.Context, change *models.RoleChange) error
+	// ListRoleChanges returns recorded role changes, oldest first; userID zero
+	// returns the changes of every user.
+	ListRoleChanges(ctx context.Context, userID int) ([]models.RoleChange, error)
+}
+
+// RefreshTokenStore persists refresh tokens.
+type RefreshTokenStore interface {
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error)
+	// UseRefreshToken marks the token as used at the given time. It fails with
+	// ErrTokenUsed unless the token is still unused and not revoked, so only
+	// one of several concurrent refreshes with the same token can succeed.
+	UseRefreshToken(ctx context.Context, id int, at time.Time) error
+	// RevokeTokenFamily revokes every token that shares familyID.
+	RevokeTokenFamily(ctx context.Context, familyID string, at time.Time) error
+}
+
+// Store bundles every storage interface the API needs.
+type Store interface {
+	TodoStore
+	TrashStore
+	HistoryStore
+	RankStore
+	ProjectStore
+	StatusStore
+	SeriesStore
+	TagStore
+	DependencyStore
+	TimeEntryStore
+	AttachmentStore
+	CommentStore
+	ShareStore
+	ReminderStore
+	UserStore
+	RefreshTokenStore
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/db"
+	"github.com/Anwarjondev/todo-api-go/models"
+)
+
+// testStores returns an empty memory store and an empty SQLite store, for
+// tests that check both backends behave alike.
+func testStores(t *testing.T) map[string]Store {
+	t.Helper()
+	t.Setenv("DB_PATH", filepath.Join(t.TempDir(), "todos.db"))
+	conn := db.InitDB(db.SQLite)
+	t.Cleanup(func() { conn.Close() })
+	return map[string]Store{
+		"memory": NewMemoryStore(),
+		"sqlite": NewSQLStore(conn, db.SQLite),
+	}
+}
+
+// createUser stores a new user with the given role.
+func createUser(t *testing.T, s Store, username, role string) models.User {
+	t.Helper()
+	user := models.User{Username: username, Password: "x", Role: role}
+	if err := s.CreateUser(context.Background(), &user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestSetUserRoleKeepsAnAdmin(t *testing.T) {
+	ctx := context.Background()
+	for round := 0; round < 10; round++ {
+		for name, s := range testStores(t) {
+			// Two admins, the only ones, demote each other at once.
+			admins := []models.User{createUser(t, s, "alice", "admin"), createUser(t, s, "bob", "admin")}
+			errs := make([]error, len(admins))
+			var wg sync.WaitGroup
+			for i, admin := range admins {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					change := models.RoleChange{UserID: admin.ID, NewRole: "user", ChangedAt: time.Now().UTC()}
+					errs[i] = s.SetUserRole(ctx, &change)
+				}()
+			}
+			wg.Wait()
+
+			refused := 0
+			for _, err := range errs {
+				if errors.Is(err, ErrLastAdmin) {
+					refused++
+				} else if err != nil {
+					t.Fatalf("%s: %v", name, err)
+				}
+			}
+			users, err := s.ListUsers(ctx)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			remaining := 0
+			for _, u := range users {
+				if u.Role == "admin" {
+					remaining++
+				}
+			}
+			if refused != 1 || remaining != 1 {
+				t.Fatalf("%s: %d demotions refused and %d admins left, want 1 and 1", name, refused, remaining)
+			}
+		}
+	}
+}
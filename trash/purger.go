@@ -0,0 +1,65 @@
+// Package trash empties the trash of deleted todos once they have been there
+// longer than a retention period.
+package trash
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Anwarjondev/todo-api-go/blobs"
+)
+
+// Store is the storage the Purger works on.
+type Store interface {
+	// PurgeTrash permanently deletes every todo moved to the trash before
+	// before, returning the blob keys of their attachments.
+	PurgeTrash(ctx context.Context, before time.Time) ([]string, error)
+}
+
+// Purger periodically purges todos that have been in the trash for longer
+// than Retention, together with their attachment files.
+type Purger struct {
+	Store Store
+	Blobs blobs.BlobStore
+	// Retention is how long deleted todos can be restored.
+	Retention time.Duration
+	// Interval is how often the trash is checked for expired todos.
+	Interval time.Duration
+}
+
+func NewPurger(s Store, files blobs.BlobStore) *Purger {
+	return &Purger{Store: s, Blobs: files, Retention: 30 * 24 * time.Hour, Interval: time.Hour}
+}
+
+// Run purges expired todos until ctx is cancelled.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+	for {
+		if err := p.Purge(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			log.Printf("Trash purge failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge permanently deletes the todos that were moved to the trash more
+// than Retention before now.
+func (p *Purger) Purge(ctx context.Context, now time.Time) error {
+	keys, err := p.Store.PurgeTrash(ctx, now.Add(-p.Retention))
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		// A failure only leaves an orphaned file behind.
+		if err := p.Blobs.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete blob %s: %v", key, err)
+		}
+	}
+	return nil
+}